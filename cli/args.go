@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"github.com/urfave/cli"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+const OPT_TERRAGRUNT_CONFIG = "terragrunt-config"
+const OPT_TERRAGRUNT_WORKING_DIR = "terragrunt-working-dir"
+const OPT_TERRAGRUNT_SOURCE = "terragrunt-source"
+const OPT_TERRAGRUNT_PARALLELISM = "terragrunt-parallelism"
+const OPT_TERRAGRUNT_TFPATH = "terragrunt-tfpath"
+const OPT_TERRAGRUNT_ENV = "terragrunt-env"
+const OPT_TERRAGRUNT_NON_INTERACTIVE = "terragrunt-non-interactive"
+
+// The Terragrunt-specific options can also be set using these environment variables
+const TERRAGRUNT_CONFIG_ENV_VAR = "TERRAGRUNT_CONFIG"
+const TERRAGRUNT_WORKING_DIR_ENV_VAR = "TERRAGRUNT_WORKING_DIR"
+const TERRAGRUNT_SOURCE_ENV_VAR = "TERRAGRUNT_SOURCE"
+const TERRAGRUNT_PARALLELISM_ENV_VAR = "TERRAGRUNT_PARALLELISM"
+const TERRAGRUNT_TFPATH_ENV_VAR = "TERRAGRUNT_TFPATH"
+const TERRAGRUNT_ENV_ENV_VAR = "TERRAGRUNT_ENV"
+const TERRAGRUNT_NON_INTERACTIVE_ENV_VAR = "TERRAGRUNT_NON_INTERACTIVE"
+
+// The global options Terragrunt understands. These are only used by urfave/cli to parse options that come before the
+// Terraform command and to show the help text; options that come after the command are parsed by parseTerragruntArgs.
+var TERRAGRUNT_FLAGS = []cli.Flag{
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_CONFIG,
+		EnvVar: TERRAGRUNT_CONFIG_ENV_VAR,
+		Usage: "Path to the Terragrunt config file. Default is .terragrunt (or, if there is none, .terragrunt.json) in the working directory.",
+	},
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_WORKING_DIR,
+		EnvVar: TERRAGRUNT_WORKING_DIR_ENV_VAR,
+		Usage: "The path to the Terraform templates. Default is the current directory.",
+	},
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_SOURCE,
+		EnvVar: TERRAGRUNT_SOURCE_ENV_VAR,
+		Usage: "Download Terraform templates from this source instead of the terraform.source in the Terragrunt config, e.g. a local checkout of the repository.",
+	},
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_PARALLELISM,
+		EnvVar: TERRAGRUNT_PARALLELISM_ENV_VAR,
+		Usage: "The maximum number of modules the plan-all, apply-all, and destroy-all commands run in at the same time. Default is 1.",
+	},
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_TFPATH,
+		EnvVar: TERRAGRUNT_TFPATH_ENV_VAR,
+		Usage: "Path to the Terraform binary to run. Overrides the terraformBinary in the Terragrunt config. Default is terraform from the PATH.",
+	},
+	cli.StringFlag{
+		Name: OPT_TERRAGRUNT_ENV,
+		EnvVar: TERRAGRUNT_ENV_ENV_VAR,
+		Usage: "The environment block in the Terragrunt config whose lock and remote state settings to use, e.g. prod.",
+	},
+	cli.BoolFlag{
+		Name: OPT_TERRAGRUNT_NON_INTERACTIVE,
+		EnvVar: TERRAGRUNT_NON_INTERACTIVE_ENV_VAR,
+		Usage: "Never prompt for input. Prompts with a safe default answer get it; the others fail. Also set when TF_INPUT is 0 or false.",
+	},
+}
+
+// Parse the Terragrunt-specific options out of the command-line args and create the TerragruntOptions object used for
+// the rest of the run. Since Terragrunt forwards all other args to Terraform, and Terraform options may come after the
+// command (e.g. terragrunt plan -input=false), the Terragrunt options may appear anywhere in the args.
+func parseTerragruntOptions(cliContext *cli.Context) (*options.TerragruntOptions, error) {
+	args, workingDir, err := extractStringArg(cliContext.Args(), OPT_TERRAGRUNT_WORKING_DIR)
+	if err != nil {
+		return nil, err
+	}
+	if workingDir == "" {
+		workingDir = cliContext.String(OPT_TERRAGRUNT_WORKING_DIR)
+	}
+
+	args, configPath, err := extractStringArg(args, OPT_TERRAGRUNT_CONFIG)
+	if err != nil {
+		return nil, err
+	}
+	if configPath == "" {
+		configPath = cliContext.String(OPT_TERRAGRUNT_CONFIG)
+	}
+	if configPath == "" {
+		configPath = config.DefaultConfigPath(workingDir)
+	}
+
+	args, terraformSource, err := extractStringArg(args, OPT_TERRAGRUNT_SOURCE)
+	if err != nil {
+		return nil, err
+	}
+	if terraformSource == "" {
+		terraformSource = cliContext.String(OPT_TERRAGRUNT_SOURCE)
+	}
+
+	args, parallelismValue, err := extractStringArg(args, OPT_TERRAGRUNT_PARALLELISM)
+	if err != nil {
+		return nil, err
+	}
+	if parallelismValue == "" {
+		parallelismValue = cliContext.String(OPT_TERRAGRUNT_PARALLELISM)
+	}
+	parallelism, err := parseParallelism(parallelismValue)
+	if err != nil {
+		return nil, err
+	}
+
+	args, terraformPath, err := extractStringArg(args, OPT_TERRAGRUNT_TFPATH)
+	if err != nil {
+		return nil, err
+	}
+	if terraformPath == "" {
+		terraformPath = cliContext.String(OPT_TERRAGRUNT_TFPATH)
+	}
+	if terraformPath == "" {
+		terraformPath = options.DEFAULT_TERRAFORM_PATH
+	}
+	terraformPath, err = absTerraformPath(terraformPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	args, environment, err := extractStringArg(args, OPT_TERRAGRUNT_ENV)
+	if err != nil {
+		return nil, err
+	}
+	if environment == "" {
+		environment = cliContext.String(OPT_TERRAGRUNT_ENV)
+	}
+
+	args, nonInteractive := extractBoolArg(args, OPT_TERRAGRUNT_NON_INTERACTIVE)
+	if !nonInteractive {
+		nonInteractive = cliContext.Bool(OPT_TERRAGRUNT_NON_INTERACTIVE)
+	}
+
+	terragruntOptions := options.NewTerragruntOptions(configPath)
+	terragruntOptions.TerraformCliArgs = args
+	terragruntOptions.WorkingDir = workingDir
+	terragruntOptions.Source = terraformSource
+	terragruntOptions.Environment = environment
+	terragruntOptions.TerraformPath = terraformPath
+	terragruntOptions.Parallelism = parallelism
+	terragruntOptions.NonInteractive = nonInteractive || tfInputDisabled(terragruntOptions.Env)
+
+	return terragruntOptions, nil
+}
+
+// Return true if the TF_INPUT environment variable in the given environment tells Terraform not to ask for input
+func tfInputDisabled(env map[string]string) bool {
+	switch strings.ToLower(env[options.TF_INPUT_ENV_VAR]) {
+	case "0", "false": return true
+	default: return false
+	}
+}
+
+// Parse the given value of the --terragrunt-parallelism option, which must be a positive number. Return the default
+// parallelism if the value is empty.
+func parseParallelism(value string) (int, error) {
+	if value == "" {
+		return options.DEFAULT_PARALLELISM, nil
+	}
+
+	parallelism, err := strconv.Atoi(value)
+	if err != nil || parallelism < 1 {
+		return 0, errors.WithStackTrace(InvalidParallelism(value))
+	}
+
+	return parallelism, nil
+}
+
+// Find the option with the given name in the given args, specified as either --name value or --name=value, and return
+// the args without that option, and the value of the option (or an empty string if the option isn't specified)
+func extractStringArg(args []string, optionName string) ([]string, string, error) {
+	remainingArgs := []string{}
+	value := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		trimmedArg := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+
+		if trimmedArg == arg {
+			remainingArgs = append(remainingArgs, arg)
+		} else if trimmedArg == optionName {
+			if i + 1 >= len(args) {
+				return nil, "", errors.WithStackTrace(ArgMissing(optionName))
+			}
+			value = args[i + 1]
+			i++
+		} else if strings.HasPrefix(trimmedArg, optionName + "=") {
+			value = strings.TrimPrefix(trimmedArg, optionName + "=")
+		} else {
+			remainingArgs = append(remainingArgs, arg)
+		}
+	}
+
+	return remainingArgs, value, nil
+}
+
+// Find the option with the given name, which takes no value, in the given args, specified as --name, and return the
+// args without that option, and whether it was specified
+func extractBoolArg(args []string, optionName string) ([]string, bool) {
+	remainingArgs := []string{}
+	found := false
+
+	for _, arg := range args {
+		if arg == "--" + optionName || arg == "-" + optionName {
+			found = true
+		} else {
+			remainingArgs = append(remainingArgs, arg)
+		}
+	}
+
+	return remainingArgs, found
+}
+
+type ArgMissing string
+
+func (optionName ArgMissing) Error() string {
+	return fmt.Sprintf("You must specify a value for the --%s option", string(optionName))
+}
+
+type InvalidParallelism string
+
+func (value InvalidParallelism) Error() string {
+	return fmt.Sprintf("The value of the --%s option must be a positive number, but got %s", OPT_TERRAGRUNT_PARALLELISM, string(value))
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+func TestExtractBoolArg(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args          []string
+		expectedArgs  []string
+		expectedFound bool
+	}{
+		{[]string{}, []string{}, false},
+		{[]string{"release-lock"}, []string{"release-lock"}, false},
+		{[]string{"release-lock", "--terragrunt-non-interactive"}, []string{"release-lock"}, true},
+		{[]string{"-terragrunt-non-interactive", "apply-all", "-force"}, []string{"apply-all", "-force"}, true},
+	}
+
+	for _, testCase := range testCases {
+		actualArgs, actualFound := extractBoolArg(testCase.args, OPT_TERRAGRUNT_NON_INTERACTIVE)
+		assert.Equal(t, testCase.expectedArgs, actualArgs, "For args %v", testCase.args)
+		assert.Equal(t, testCase.expectedFound, actualFound, "For args %v", testCase.args)
+	}
+}
+
+func TestTfInputDisabled(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		env      map[string]string
+		expected bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{options.TF_INPUT_ENV_VAR: "1"}, false},
+		{map[string]string{options.TF_INPUT_ENV_VAR: "true"}, false},
+		{map[string]string{options.TF_INPUT_ENV_VAR: "0"}, true},
+		{map[string]string{options.TF_INPUT_ENV_VAR: "FALSE"}, true},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, tfInputDisabled(testCase.env), "For env %v", testCase.env)
+	}
+}
@@ -3,13 +3,20 @@ package cli
 import (
 	"github.com/urfave/cli"
 	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
 	"github.com/gruntwork-io/terragrunt/locks"
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/shell"
 	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/gruntwork-io/terragrunt/remote"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/source"
 	"regexp"
+	"io"
+	"encoding/json"
+	"path/filepath"
+	"strings"
 )
 
 // Since Terragrunt is just a thin wrapper for Terraform, and we don't want to repeat every single Terraform command
@@ -25,6 +32,18 @@ COMMANDS:
    apply                Acquire a lock and run 'terraform apply'
    destroy              Acquire a lock and run 'terraform destroy'
    release-lock         Release a lock that is left over from some previous command
+                        (use -force to release it without asking when running non-interactively)
+   plan-all             Run 'terragrunt plan' in each folder with a .terragrunt file under the current folder
+   apply-all            Run 'terragrunt apply' in each folder with a .terragrunt file, in dependency order
+   destroy-all          Run 'terragrunt destroy' in each folder with a .terragrunt file, in reverse dependency order
+   state-scan           Scan state files for values that look like secrets (use -json for machine-readable output)
+   state-restore        Acquire a lock and restore a state backup (the most recent one if no backup is specified)
+                        (use -force to restore a backup whose lineage doesn't match the current state)
+   migrate-state        Acquire a lock and copy state from the currently configured backend to the one in .terragrunt
+                        (use -dry-run to only check and show what would happen)
+   validate-config      Check every .terragrunt file under the current folder without running Terraform
+   render-config        Print the effective .terragrunt config, with includes and interpolations resolved
+                        (use -json for machine-readable output)
    *                    Terragrunt forwards all other commands directly to Terraform
 {{if .VisibleFlags}}
 GLOBAL OPTIONS:
@@ -39,6 +58,11 @@ AUTHOR(S):
 `
 
 var MODULE_REGEX = regexp.MustCompile(`module ".+"`)
+
+// Commands that run a Terraform command in every module under the working directory. The Terraform command is the
+// name of the command without the suffix.
+var MULTI_MODULE_COMMANDS = []string{"plan-all", "apply-all", "destroy-all"}
+const MULTI_MODULE_COMMAND_SUFFIX = "-all"
 const TERRAFORM_EXTENSION_GLOB = "*.tf"
 
 // Create the Terragrunt CLI App
@@ -51,6 +75,7 @@ func CreateTerragruntCli(version string) *cli.App {
 	app.Author = "Gruntwork <www.gruntwork.io>"
 	app.Version = version
 	app.Action = runApp
+	app.Flags = TERRAGRUNT_FLAGS
 	app.Usage = "terragrunt <COMMAND>"
 	app.UsageText = `Terragrunt is a thin wrapper for [Terraform](https://www.terraform.io/) that supports locking
    via Amazon's DynamoDB and enforces best practices. Terragrunt forwards almost all commands, arguments, and options
@@ -62,78 +87,351 @@ func CreateTerragruntCli(version string) *cli.App {
 	return app
 }
 
-// The sole action for the app. It forwards all commands directly to Terraform, enforcing a few best practices along
-// the way, such as configuring remote state or acquiring a lock.
+// The sole action for the app. It parses the command-line args into a TerragruntOptions object and runs Terragrunt
+// with it.
 func runApp(cliContext *cli.Context) (finalErr error) {
 	defer errors.Recover(func(cause error) { finalErr = cause })
 
+	terragruntOptions, err := parseTerragruntOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
 	// If someone calls us with no args at all, show the help text and exit
-	if !cliContext.Args().Present() {
+	if len(terragruntOptions.TerraformCliArgs) == 0 {
 		cli.ShowAppHelp(cliContext)
 		return nil
 	}
 
-	terragruntConfig, err := config.ReadTerragruntConfig()
+	terragruntOptions.TerragruntVersion = cliContext.App.Version
+
+	return RunTerragrunt(terragruntOptions)
+}
+
+// Run Terragrunt with the given options. This forwards the Terraform command in the options directly to Terraform,
+// enforcing a few best practices along the way, such as configuring remote state or acquiring a lock. Other Go
+// programs can call this function to run Terragrunt without going through the command line.
+func RunTerragrunt(terragruntOptions *options.TerragruntOptions) error {
+	switch terragruntOptions.TerraformCommand() {
+	case "validate-config": return runValidateConfigCommand(terragruntOptions)
+	case "render-config": return runRenderConfigCommand(terragruntOptions)
+	}
+
+	if isMultiModuleCommand(terragruntOptions.TerraformCommand()) {
+		return runMultiModuleCommand(terragruntOptions)
+	}
+
+	terragruntConfig, err := config.ReadTerragruntConfig(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	terragruntConfig.RegisterSecrets()
+
+	if err := resolveTerraformPath(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	if err := checkVersionConstraints(terragruntOptions, terragruntConfig); err != nil {
+		return err
+	}
+
+	if sourceUrl := getTerraformSource(terragruntOptions, terragruntConfig); sourceUrl != "" {
+		return runTerragruntWithSource(sourceUrl, terragruntOptions, terragruntConfig)
+	}
+
+	return runTerragruntInWorkingDir(terragruntOptions, terragruntConfig)
+}
+
+// Download the Terraform templates from the given source, run Terragrunt in the folder they were downloaded to, and
+// then copy any local state files there back to the working directory of the given options, even if Terraform failed,
+// so the state isn't lost when the source is downloaded again
+func runTerragruntWithSource(sourceUrl string, terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	downloadedOptions, err := downloadTerraformSource(sourceUrl, terragruntOptions, terragruntConfig)
 	if err != nil {
 		return err
 	}
 
-	if err := downloadModules(cliContext); err != nil {
+	runErr := runTerragruntInWorkingDir(downloadedOptions, terragruntConfig)
+
+	if err := source.CopyStateFiles(downloadedOptions.WorkingDir, terragruntOptions.WorkingDir); err != nil {
+		if runErr == nil {
+			return err
+		}
+		terragruntOptions.Logger.Printf("ERROR: failed to copy state files from %s to %s: %s", downloadedOptions.WorkingDir, terragruntOptions.WorkingDir, errors.PrintErrorWithStackTrace(err))
+	}
+
+	return runErr
+}
+
+// Run the Terraform command in the given options in their working directory, configuring remote state and acquiring a
+// lock first, if the given Terragrunt config asks for it
+func runTerragruntInWorkingDir(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	statePaths := remote.ResolveStatePaths(terragruntOptions)
+	statePaths.RemoteStateConfigured = terragruntConfig.RemoteState != nil
+
+	if terragruntConfig.RemoteState != nil {
+		remote.WarnAboutLocalStateFiles(statePaths, terragruntOptions)
+	}
+
+	if err := downloadModules(terragruntOptions); err != nil {
 		return err
 	}
 
 	if terragruntConfig.RemoteState != nil {
-		if err := configureRemoteState(cliContext, terragruntConfig.RemoteState); err != nil {
+		if err := configureRemoteState(terragruntOptions, terragruntConfig.RemoteState, statePaths); err != nil {
+			return err
+		}
+	}
+
+	if stateId := getStateId(terragruntConfig); stateId != "" {
+		if err := checkStatePin(terragruntOptions, stateId, statePaths); err != nil {
 			return err
 		}
 	}
 
 	if terragruntConfig.DynamoDbLock != nil {
-		return runTerraformCommandWithLock(cliContext, terragruntConfig.DynamoDbLock)
+		return runTerraformCommandWithLock(terragruntOptions, terragruntConfig.DynamoDbLock, terragruntConfig, statePaths)
 	} else {
-		util.Logger.Printf("WARNING: you have not configured locking in your .terragrunt file. Concurrent changes to your .tfstate files may cause conflicts!")
-		return runTerraformCommand(cliContext)
+		terragruntOptions.Logger.Printf("WARNING: you have not configured locking in your .terragrunt file. Concurrent changes to your .tfstate files may cause conflicts!")
+		return runTerraformCommandWithoutLock(terragruntOptions, terragruntConfig, statePaths)
+	}
+}
+
+// Choose the Terraform binary the given options run, unless the --terragrunt-tfpath option already chose one: the
+// terraformBinary in the given Terragrunt config, if set, or else the newest Terraform in the Terraform versions folder
+// that satisfies the terraformVersionConstraint, if there is one. Otherwise, terraform from the PATH is used.
+func resolveTerraformPath(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if terragruntOptions.TerraformPath != options.DEFAULT_TERRAFORM_PATH {
+		return nil
+	}
+
+	if terragruntConfig.TerraformBinary != "" {
+		terraformPath, err := absTerraformPath(terragruntConfig.TerraformBinary, terragruntOptions.WorkingDir)
+		if err != nil {
+			return err
+		}
+		terragruntOptions.TerraformPath = terraformPath
+		return nil
 	}
+
+	if terragruntConfig.TerraformVersionConstraint != "" {
+		terraformPath, err := remote.FindTerraformBinary(terragruntOptions.TerraformVersionsDir, terragruntConfig.TerraformVersionConstraint)
+		if err != nil {
+			return err
+		}
+		if terraformPath != "" {
+			terragruntOptions.Logger.Printf("Using %s, which satisfies the terraformVersionConstraint %s", terraformPath, terragruntConfig.TerraformVersionConstraint)
+			terragruntOptions.TerraformPath = terraformPath
+		}
+	}
+
+	return nil
 }
 
-// A quick sanity check that calls `terraform get` to download modules, if they aren't already downloaded.
-func downloadModules(cliContext *cli.Context) error {
-	switch cliContext.Args().First() {
+// Return the given path to a Terraform binary, made absolute using the given working directory if it's a relative path
+// such as ./bin/terraform. A plain name such as terraform-0.9 is returned as is, so it's looked up in the PATH.
+func absTerraformPath(terraformPath string, workingDir string) (string, error) {
+	if filepath.IsAbs(terraformPath) || !strings.ContainsRune(terraformPath, filepath.Separator) {
+		return terraformPath, nil
+	}
+
+	absPath, err := filepath.Abs(filepath.Join(workingDir, terraformPath))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return absPath, nil
+}
+
+// Check that the versions of Terraform and Terragrunt satisfy the version constraints in the given Terragrunt config.
+// If this is a development build of Terragrunt without a version, the Terragrunt version is not checked.
+func checkVersionConstraints(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) error {
+	if terragruntConfig.TerragruntVersionConstraint != "" {
+		if terragruntOptions.TerragruntVersion == "" {
+			terragruntOptions.Logger.Printf("WARNING: not checking the terragruntVersionConstraint, as this build of Terragrunt has no version")
+		} else if err := terragruntConfig.CheckTerragruntVersion(terragruntOptions.TerragruntVersion); err != nil {
+			return err
+		}
+	}
+
+	if terragruntConfig.TerraformVersionConstraint != "" {
+		terraformVersion, err := remote.GetTerraformVersion(terragruntOptions)
+		if err != nil {
+			return err
+		}
+		if err := terragruntConfig.CheckTerraformVersion(terraformVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return true if the given command runs a Terraform command in every module under the working directory
+func isMultiModuleCommand(command string) bool {
+	for _, multiModuleCommand := range MULTI_MODULE_COMMANDS {
+		if command == multiModuleCommand {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run the Terraform command of the given multi-module command, such as apply for apply-all, in every module under the
+// working directory, in dependency order (or reverse dependency order for destroy). The apply-all and destroy-all
+// commands prompt the user for confirmation first.
+func runMultiModuleCommand(terragruntOptions *options.TerragruntOptions) error {
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	command := strings.TrimSuffix(terragruntOptions.TerraformCommand(), MULTI_MODULE_COMMAND_SUFFIX)
+	reverse := command == "destroy"
+
+	if command == "apply" || command == "destroy" {
+		proceed, err := confirmMultiModuleCommand(command, stack, reverse, terragruntOptions)
+		if err != nil || !proceed {
+			return err
+		}
+	}
+
+	terraformArgs := append([]string{command}, commandArgs(terragruntOptions)...)
+	return stack.Run(terraformArgs, reverse, terragruntOptions.Parallelism, RunTerragrunt, terragruntOptions.Writer)
+}
+
+// Show the user the modules the given command will run in, in the order it will run in them, and ask them to confirm
+func confirmMultiModuleCommand(command string, stack *configstack.Stack, reverse bool, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	fmt.Fprintf(terragruntOptions.Writer, "Terragrunt will run 'terragrunt %s' in the following modules, in this order:\n", command)
+	for _, module := range stack.ModulesInRunOrder(reverse) {
+		fmt.Fprintf(terragruntOptions.Writer, "  %s\n", module.Path)
+	}
+
+	return shell.PromptUserForYesNo(fmt.Sprintf("Are you sure you want to run 'terragrunt %s' in each of these modules?", command), multiModuleCommandAnswer(command, terragruntOptions), terragruntOptions)
+}
+
+// Return the answer to the confirmation of the given multi-module command when Terragrunt is running
+// non-interactively. Running apply in every module is what the user asked for, so the answer is yes, but destroying
+// every module is only safe if the user also passed -force, which is how Terraform itself skips confirming a destroy.
+func multiModuleCommandAnswer(command string, terragruntOptions *options.TerragruntOptions) shell.NonInteractiveAnswer {
+	if command == "apply" {
+		return shell.ANSWER_YES
+	}
+
+	return forceAnswer(terragruntOptions)
+}
+
+// Return yes as the non-interactive answer to a confirmation if the user passed -force after the command, and no safe
+// answer otherwise
+func forceAnswer(terragruntOptions *options.TerragruntOptions) shell.NonInteractiveAnswer {
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch arg {
+		case "-force", "--force": return shell.ANSWER_YES
+		}
+	}
+
+	return shell.NO_SAFE_ANSWER
+}
+
+// Return the Terraform source to download the templates from: the source in the given options, if it's set, or the
+// terraform.source in the given Terragrunt config. If the source in the options is used, the subdir of the source in
+// the config, if any, is appended to it. Return an empty string if there is no source.
+func getTerraformSource(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) string {
+	configSource := ""
+	if terragruntConfig.Terraform != nil {
+		configSource = terragruntConfig.Terraform.Source
+	}
+
+	if terragruntOptions.Source != "" {
+		return source.OverrideTerraformSource(configSource, terragruntOptions.Source)
+	}
+
+	return configSource
+}
+
+// Return the folder Terraform runs in when Terragrunt runs with the given options in the given folder: the folder the
+// Terraform source of its Terragrunt config is downloaded to, if it has one, or the folder itself otherwise
+func findTerraformDir(dir string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	dirOptions := terragruntOptions.Clone()
+	dirOptions.WorkingDir = dir
+	dirOptions.TerragruntConfigPath = config.DefaultConfigPath(dir)
+
+	if !util.IsFile(dirOptions.TerragruntConfigPath) {
+		return dir, nil
+	}
+
+	terragruntConfig, err := config.ReadTerragruntConfig(dirOptions)
+	if err != nil {
+		return "", err
+	}
+
+	sourceUrl := getTerraformSource(dirOptions, terragruntConfig)
+	if sourceUrl == "" {
+		return dir, nil
+	}
+
+	return source.FindTerraformDir(sourceUrl, dirOptions)
+}
+
+// Download the Terraform templates from the given source and return a copy of the given options that runs Terraform in
+// the folder they were downloaded to. The paths in the given Terragrunt config that are relative to the original
+// working directory are made absolute, so they still point to the same place.
+func downloadTerraformSource(sourceUrl string, terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig) (*options.TerragruntOptions, error) {
+	terraformDir, err := source.DownloadTerraformSource(sourceUrl, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := terragruntConfig.MakePathsAbsolute(terragruntOptions.WorkingDir); err != nil {
+		return nil, err
+	}
+
+	downloadedOptions := terragruntOptions.Clone()
+	downloadedOptions.WorkingDir = terraformDir
+
+	return downloadedOptions, nil
+}
+
+// A quick sanity check that calls `terraform get` to download modules in the working directory of the given options,
+// if they aren't already downloaded.
+func downloadModules(terragruntOptions *options.TerragruntOptions) error {
+	switch terragruntOptions.TerraformCommand() {
 	case "apply", "destroy", "graph", "output", "plan", "show", "taint", "untaint", "validate":
-		shouldDownload, err := shouldDownloadModules()
+		shouldDownload, err := shouldDownloadModules(terragruntOptions.WorkingDir)
 		if err != nil {
 			return err
 		}
 		if shouldDownload {
-			return shell.RunShellCommand("terraform", "get", "-update")
+			return shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, "get", "-update")
 		}
 	}
 
 	return nil
 }
 
-// Return true if modules aren't already downloaded and the Terraform templates in this project reference modules.
-// Note that to keep the logic in this code very simple, this code ONLY detects the case where you haven't downloaded
-// modules at all. Detecting if your downloaded modules are out of date (as opposed to missing entirely) is more
-// complicated and not something we handle at the moment.
-func shouldDownloadModules() (bool, error) {
-	if util.FileExists(".terraform/modules") {
+// Return true if modules aren't already downloaded and the Terraform templates in the given working directory
+// reference modules. Note that to keep the logic in this code very simple, this code ONLY detects the case where you
+// haven't downloaded modules at all. Detecting if your downloaded modules are out of date (as opposed to missing
+// entirely) is more complicated and not something we handle at the moment.
+func shouldDownloadModules(workingDir string) (bool, error) {
+	if util.FileExists(filepath.Join(workingDir, ".terraform/modules")) {
 		return false, nil
 	}
 
-	return util.Grep(MODULE_REGEX, TERRAFORM_EXTENSION_GLOB)
+	return util.Grep(MODULE_REGEX, filepath.Join(workingDir, TERRAFORM_EXTENSION_GLOB))
 }
 
 // If the user entered a Terraform command that uses state (e.g. plan, apply), make sure remote state is configured
 // before running the command.
-func configureRemoteState(cliContext *cli.Context, remoteState *remote.RemoteState) error {
+func configureRemoteState(terragruntOptions *options.TerragruntOptions, remoteState *remote.RemoteState, statePaths remote.StatePaths) error {
 	// We only configure remote state for the commands that use the tfstate files. We do not configure it for
 	// commands such as "get" or "version".
-	switch cliContext.Args().First() {
+	switch terragruntOptions.TerraformCommand() {
 	case "apply", "destroy", "graph", "output", "plan", "push", "refresh", "show", "taint", "untaint", "validate":
-		return remoteState.ConfigureRemoteState()
+		return remoteState.ConfigureRemoteState(statePaths, terragruntOptions)
 	case "remote":
-		if cliContext.Args().Get(1) == "config" {
+		if len(terragruntOptions.TerraformCliArgs) > 1 && terragruntOptions.TerraformCliArgs[1] == "config" {
 			// Encourage the user to configure remote state by defining it in .terragrunt and letting
 			// Terragrunt handle it for them
 			return errors.WithStackTrace(DontManuallyConfigureRemoteState)
@@ -147,32 +445,278 @@ func configureRemoteState(cliContext *cli.Context, remoteState *remote.RemoteSta
 	return nil
 }
 
+// Return the id under which to check the lineage and serial of the state for the given Terragrunt config: the
+// stateFileId of the lock, if there is one, or else an id for the remote state settings. Return an empty string if
+// neither is configured.
+func getStateId(terragruntConfig *config.TerragruntConfig) string {
+	if terragruntConfig.DynamoDbLock != nil {
+		return terragruntConfig.DynamoDbLock.StateFileId
+	}
+
+	if terragruntConfig.RemoteState != nil {
+		return terragruntConfig.RemoteState.StateId()
+	}
+
+	return ""
+}
+
+// If the user entered a Terraform command that uses state (e.g. plan, apply), make sure the state has the lineage we
+// expect for the given state id and that its serial hasn't gone backwards. This protects against accidentally
+// pointing Terragrunt at the state of a different set of templates and overwriting it.
+func checkStatePin(terragruntOptions *options.TerragruntOptions, stateId string, statePaths remote.StatePaths) error {
+	switch terragruntOptions.TerraformCommand() {
+	case "apply", "destroy", "graph", "output", "plan", "push", "refresh", "show", "taint", "untaint", "validate":
+		return remote.CheckStatePin(stateId, statePaths, terragruntOptions)
+	}
+
+	return nil
+}
+
 // Run the given Terraform command with the given lock (if the command requires locking)
-func runTerraformCommandWithLock(cliContext *cli.Context, lock locks.Lock) error {
-	switch cliContext.Args().First() {
-	case "apply", "destroy": return locks.WithLock(lock, func() error { return runTerraformCommand(cliContext) })
-	case "release-lock": return runReleaseLockCommand(cliContext, lock)
-	default: return runTerraformCommand(cliContext)
+func runTerraformCommandWithLock(terragruntOptions *options.TerragruntOptions, lock locks.Lock, terragruntConfig *config.TerragruntConfig, statePaths remote.StatePaths) error {
+	switch terragruntOptions.TerraformCommand() {
+	case "apply", "destroy": return locks.WithLock(lock, terragruntOptions, func() error { return runTerraformCommandWithStateBackup(terragruntOptions, terragruntConfig, statePaths) })
+	case "state-restore": return locks.WithLock(lock, terragruntOptions, func() error { return runStateRestoreCommand(terragruntOptions, terragruntConfig.StateBackup, statePaths) })
+	case "migrate-state": return locks.WithLock(lock, terragruntOptions, func() error { return runMigrateStateCommand(terragruntOptions, terragruntConfig.RemoteState, terragruntConfig.StateBackup, statePaths) })
+	case "release-lock": return runReleaseLockCommand(terragruntOptions, lock)
+	case "state-scan": return runStateScanCommand(terragruntOptions, statePaths)
+	default: return runTerraformCommand(terragruntOptions, terragruntConfig, statePaths)
+	}
+}
+
+// Run the given Terraform command without a lock
+func runTerraformCommandWithoutLock(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig, statePaths remote.StatePaths) error {
+	switch terragruntOptions.TerraformCommand() {
+	case "apply", "destroy": return runTerraformCommandWithStateBackup(terragruntOptions, terragruntConfig, statePaths)
+	case "state-restore": return runStateRestoreCommand(terragruntOptions, terragruntConfig.StateBackup, statePaths)
+	case "migrate-state": return runMigrateStateCommand(terragruntOptions, terragruntConfig.RemoteState, terragruntConfig.StateBackup, statePaths)
+	case "state-scan": return runStateScanCommand(terragruntOptions, statePaths)
+	default: return runTerraformCommand(terragruntOptions, terragruntConfig, statePaths)
+	}
+}
+
+// Back up the current Terraform state (if state backups are configured) and run the given Terraform command
+func runTerraformCommandWithStateBackup(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig, statePaths remote.StatePaths) error {
+	if terragruntConfig.StateBackup != nil {
+		if err := terragruntConfig.StateBackup.BackupCurrentState(statePaths, terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	return runTerraformCommand(terragruntOptions, terragruntConfig, statePaths)
+}
+
+// Run the Terraform command in the given options, along with the before and after hooks from the Terragrunt config that
+// apply to it. If a before hook fails, Terraform is not run. After hooks run only if everything before them succeeded,
+// unless they are configured to run on error.
+func runTerraformCommand(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig, statePaths remote.StatePaths) error {
+	command := terragruntOptions.TerraformCommand()
+
+	err := runHooks(config.HooksForCommand(terragruntConfig.BeforeHooks, command), terragruntOptions, nil)
+	if err == nil {
+		err = runTerraform(terragruntOptions, terragruntConfig, statePaths)
+	}
+
+	return runHooks(config.HooksForCommand(terragruntConfig.AfterHooks, command), terragruntOptions, err)
+}
+
+// Run the Terraform command in the given options in their working directory. Any extraArguments blocks in the
+// Terragrunt config that apply to the command add their arguments and environment variables. If the Terragrunt config
+// declares dependencies and the command accepts variables, pass the outputs of those dependencies to Terraform in a var
+// file.
+func runTerraform(terragruntOptions *options.TerragruntOptions, terragruntConfig *config.TerragruntConfig, statePaths remote.StatePaths) error {
+	command := terragruntOptions.TerraformCommand()
+	argsToInsert, extraEnvVars := config.ExtraArgumentsForCommand(terragruntConfig.ExtraArguments, command, terragruntOptions.WorkingDir)
+
+	if len(terragruntConfig.Dependencies) > 0 && commandAcceptsVarFiles(command) {
+		varFilePath := filepath.Join(statePaths.WorkingDir, remote.DEPENDENCY_VAR_FILE)
+		findTerraformDir := func(dependencyDir string) (string, error) { return findTerraformDir(dependencyDir, terragruntOptions) }
+		if err := remote.WriteDependencyVarFile(terragruntConfig.Dependencies, varFilePath, findTerraformDir, terragruntOptions); err != nil {
+			return err
+		}
+		argsToInsert = append(argsToInsert, fmt.Sprintf("-var-file=%s", remote.DEPENDENCY_VAR_FILE))
+	}
+
+	commandOptions := terragruntOptions.Clone()
+	for name, value := range extraEnvVars {
+		commandOptions.Env[name] = value
+	}
+
+	terraformArgs := insertArgsAfterCommand(terragruntOptions.TerraformCliArgs, argsToInsert...)
+	return shell.RunShellCommandWithOptions(commandOptions, terragruntOptions.TerraformPath, terraformArgs...)
+}
+
+// Return true if the given Terraform command accepts the -var-file option
+func commandAcceptsVarFiles(command string) bool {
+	switch command {
+	case "apply", "console", "destroy", "import", "plan", "push", "refresh":
+		return true
+	}
+
+	return false
+}
+
+// Insert the given arguments right after the Terraform command (the first argument), so they come before any
+// arguments, such as a plan file or folder, that Terraform expects at the end
+func insertArgsAfterCommand(args []string, argsToInsert ... string) []string {
+	if len(args) == 0 {
+		return argsToInsert
+	}
+
+	out := []string{args[0]}
+	out = append(out, argsToInsert...)
+	return append(out, args[1:]...)
+}
+
+// Restore a state backup, prompting the user for confirmation first. The name of the backup to restore is the optional
+// argument after the command; if it's not specified, the most recent backup is restored. If the -force argument is
+// specified, the backup is restored even if its lineage doesn't match that of the current state.
+func runStateRestoreCommand(terragruntOptions *options.TerragruntOptions, stateBackup *remote.StateBackup, statePaths remote.StatePaths) error {
+	if stateBackup == nil {
+		return errors.WithStackTrace(StateBackupNotConfigured)
+	}
+
+	backupName := ""
+	force := false
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch {
+		case arg == "-force" || arg == "--force": force = true
+		case backupName == "" && !strings.HasPrefix(arg, "-"): backupName = arg
+		default: return errors.WithStackTrace(UnrecognizedArgument{Command: "state-restore", Argument: arg})
+		}
+	}
+
+	description := backupName
+	if description == "" {
+		description = "the most recent state backup"
+	}
+
+	proceed, err := shell.PromptUserForYesNo(fmt.Sprintf("Are you sure you want to overwrite your current Terraform state with %s?", description), shell.NO_SAFE_ANSWER, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if proceed {
+		return stateBackup.RestoreBackup(backupName, force, statePaths, terragruntOptions)
+	} else {
+		return nil
 	}
 }
 
-// Run the given Terraform command
-func runTerraformCommand(cliContext *cli.Context) error {
-	return shell.RunShellCommand("terraform", cliContext.Args()...)
+// Migrate Terraform state from the currently configured remote backend to the one in the Terragrunt config. If the
+// -dry-run argument is specified, only check that the migration can be done and show what would happen.
+func runMigrateStateCommand(terragruntOptions *options.TerragruntOptions, remoteState *remote.RemoteState, stateBackup *remote.StateBackup, statePaths remote.StatePaths) error {
+	if remoteState == nil {
+		return errors.WithStackTrace(RemoteStateNotConfigured)
+	}
+
+	dryRun := false
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch arg {
+		case "-dry-run", "--dry-run": dryRun = true
+		default: return errors.WithStackTrace(UnrecognizedArgument{Command: "migrate-state", Argument: arg})
+		}
+	}
+
+	if stateBackup != nil && !dryRun {
+		if err := stateBackup.BackupCurrentState(statePaths, terragruntOptions); err != nil {
+			return err
+		}
+	}
+
+	return remoteState.MigrateRemoteState(dryRun, statePaths, terragruntOptions)
 }
 
-// Release a lock, prompting the user for confirmation first
-func runReleaseLockCommand(cliContext *cli.Context, lock locks.Lock) error {
-	proceed, err := shell.PromptUserForYesNo(fmt.Sprintf("Are you sure you want to release %s?", lock))
+// Scan the Terraform state for values that look like secrets and print the results, either as text or, if the -json
+// argument is specified, as JSON. Returns an error if secrets are at risk of being committed to version control, so
+// this command can be used to gate CI builds.
+func runStateScanCommand(terragruntOptions *options.TerragruntOptions, statePaths remote.StatePaths) error {
+	jsonOutput := false
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch arg {
+		case "-json", "--json": jsonOutput = true
+		default: return errors.WithStackTrace(UnrecognizedArgument{Command: "state-scan", Argument: arg})
+		}
+	}
+
+	report, err := remote.ScanStateFiles(statePaths, terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Fprintln(terragruntOptions.Writer, string(out))
+	} else {
+		printStateScanReport(report, terragruntOptions.Writer)
+	}
+
+	return report.Check()
+}
+
+// Print the given state scan report in a human-readable format to the given writer
+func printStateScanReport(report *remote.StateScanReport, writer io.Writer) {
+	for _, localStateFile := range report.LocalStateFiles {
+		if localStateFile.TrackedByGit {
+			fmt.Fprintf(writer, "%s: local state file (tracked by Git)\n", localStateFile.Path)
+		} else {
+			fmt.Fprintf(writer, "%s: local state file\n", localStateFile.Path)
+		}
+	}
+
+	for _, value := range report.SensitiveValues {
+		if value.Output != "" {
+			fmt.Fprintf(writer, "%s: module %s, output %s: %s\n", value.StateFile, value.Module, value.Output, value.Reason)
+		} else {
+			fmt.Fprintf(writer, "%s: module %s, resource %s, attribute %s: %s\n", value.StateFile, value.Module, value.Resource, value.Attribute, value.Reason)
+		}
+	}
+
+	fmt.Fprintf(writer, "Found %d local state files and %d values that look like secrets\n", len(report.LocalStateFiles), len(report.SensitiveValues))
+}
+
+// Release a lock, prompting the user for confirmation first. If the -force argument is specified, the confirmation is
+// answered yes when Terragrunt is running non-interactively.
+func runReleaseLockCommand(terragruntOptions *options.TerragruntOptions, lock locks.Lock) error {
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch arg {
+		case "-force", "--force":
+		default: return errors.WithStackTrace(UnrecognizedArgument{Command: "release-lock", Argument: arg})
+		}
+	}
+
+	proceed, err := shell.PromptUserForYesNo(fmt.Sprintf("Are you sure you want to release %s?", lock), forceAnswer(terragruntOptions), terragruntOptions)
 	if err != nil {
 		return err
 	}
 
 	if proceed {
-		return lock.ReleaseLock()
+		return lock.ReleaseLock(terragruntOptions)
 	} else {
 		return nil
 	}
 }
 
-var DontManuallyConfigureRemoteState = fmt.Errorf("Instead of manually using the 'remote config' command, define your remote state settings in .terragrunt and Terragrunt will automatically configure it for you (and all your team members) next time you run it.")
\ No newline at end of file
+// Return the arguments that come after the command in the Terraform args of the given options
+func commandArgs(terragruntOptions *options.TerragruntOptions) []string {
+	if len(terragruntOptions.TerraformCliArgs) == 0 {
+		return []string{}
+	}
+	return terragruntOptions.TerraformCliArgs[1:]
+}
+
+var DontManuallyConfigureRemoteState = fmt.Errorf("Instead of manually using the 'remote config' command, define your remote state settings in .terragrunt and Terragrunt will automatically configure it for you (and all your team members) next time you run it.")
+var StateBackupNotConfigured = fmt.Errorf("The state-restore command requires state backups to be configured with a stateBackup block in your .terragrunt file.")
+var RemoteStateNotConfigured = fmt.Errorf("The migrate-state command requires remote state to be configured with a remoteState block in your .terragrunt file.")
+
+type UnrecognizedArgument struct {
+	Command  string
+	Argument string
+}
+
+func (err UnrecognizedArgument) Error() string {
+	return fmt.Sprintf("Unrecognized argument for the %s command: %s", err.Command, err.Argument)
+}
\ No newline at end of file
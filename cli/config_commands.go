@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/configstack"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Read and validate every Terragrunt config file in the working directory and its subfolders, without running
+// Terraform, and print which ones are valid. This resolves includes and interpolations and runs all the checks
+// Terragrunt normally runs before Terraform. If all the configs are valid, also check that the dependencies between
+// the folders can be resolved. Return an error if any config is invalid, so this command can be used to gate CI builds.
+func runValidateConfigCommand(terragruntOptions *options.TerragruntOptions) error {
+	if args := commandArgs(terragruntOptions); len(args) > 0 {
+		return errors.WithStackTrace(UnrecognizedArgument{Command: "validate-config", Argument: args[0]})
+	}
+
+	rootDir, err := filepath.Abs(terragruntOptions.WorkingDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	folders, err := configstack.FindTerragruntConfigFolders(rootDir)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return errors.WithStackTrace(NoTerragruntConfigsFound(rootDir))
+	}
+
+	invalid := 0
+	for _, folder := range folders {
+		configOptions := terragruntOptions.Clone()
+		configOptions.WorkingDir = folder
+		configOptions.TerragruntConfigPath = config.DefaultConfigPath(folder)
+
+		displayPath, err := filepath.Rel(rootDir, configOptions.TerragruntConfigPath)
+		if err != nil {
+			displayPath = configOptions.TerragruntConfigPath
+		}
+
+		if _, err := config.ReadTerragruntConfig(configOptions); err != nil {
+			invalid++
+			fmt.Fprintf(terragruntOptions.Writer, "%s: invalid\n%s\n", displayPath, err)
+		} else {
+			fmt.Fprintf(terragruntOptions.Writer, "%s: valid\n", displayPath)
+		}
+	}
+
+	if invalid == 0 {
+		if _, err := configstack.FindStackInSubfolders(terragruntOptions); err != nil {
+			fmt.Fprintf(terragruntOptions.Writer, "Error resolving the dependencies between folders: %s\n", err)
+			return err
+		}
+	}
+
+	fmt.Fprintf(terragruntOptions.Writer, "Checked %d Terragrunt config files: %d valid, %d invalid\n", len(folders), len(folders) - invalid, invalid)
+
+	if invalid > 0 {
+		return errors.WithStackTrace(InvalidTerragruntConfigs{Invalid: invalid, Total: len(folders)})
+	}
+
+	return nil
+}
+
+// Print the effective Terragrunt config for the working directory, with includes merged, interpolations resolved, and
+// defaults filled in, so it's easy to see exactly which settings apply. The config is printed in HCL format or, if the
+// -json argument is specified, as JSON. Secrets, such as decrypted values and sensitive backend configs, are redacted
+// just as they are from everything Terragrunt logs.
+func runRenderConfigCommand(terragruntOptions *options.TerragruntOptions) error {
+	jsonOutput := false
+	for _, arg := range commandArgs(terragruntOptions) {
+		switch arg {
+		case "-json", "--json": jsonOutput = true
+		default: return errors.WithStackTrace(UnrecognizedArgument{Command: "render-config", Argument: arg})
+		}
+	}
+
+	terragruntConfig, err := config.ReadTerragruntConfig(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	terragruntConfig.RegisterSecrets()
+
+	if jsonOutput {
+		out, err := config.RenderConfigAsJson(terragruntConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(terragruntOptions.Writer, util.Redact(out))
+	} else {
+		fmt.Fprint(terragruntOptions.Writer, util.Redact(config.RenderConfigAsHcl(terragruntConfig)))
+	}
+
+	return nil
+}
+
+type NoTerragruntConfigsFound string
+
+func (rootDir NoTerragruntConfigsFound) Error() string {
+	return fmt.Sprintf("Could not find any %s or %s files in %s or its subfolders", config.TERRAGRUNT_CONFIG_FILE, config.TERRAGRUNT_JSON_CONFIG_FILE, string(rootDir))
+}
+
+type InvalidTerragruntConfigs struct {
+	Invalid int
+	Total   int
+}
+
+func (err InvalidTerragruntConfigs) Error() string {
+	return fmt.Sprintf("%d of %d Terragrunt config files are invalid", err.Invalid, err.Total)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const RENDER_CONFIG_TEST_CONFIG = `
+remoteState = {
+  backend = "s3"
+  backendConfigs = {
+    bucket = "my-bucket"
+    access_key = "render-config-test-access-key"
+  }
+  sensitiveBackendConfigs = ["access_key"]
+}
+`
+
+func TestRunRenderConfigCommandRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "config-commands-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, config.TERRAGRUNT_CONFIG_FILE)
+	assert.Nil(t, ioutil.WriteFile(configPath, []byte(RENDER_CONFIG_TEST_CONFIG), 0644))
+
+	for _, args := range [][]string{{"render-config"}, {"render-config", "-json"}} {
+		var out bytes.Buffer
+
+		terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+		terragruntOptions.TerraformCliArgs = args
+		terragruntOptions.WorkingDir = tmpDir
+		terragruntOptions.Writer = &out
+
+		assert.Nil(t, runRenderConfigCommand(terragruntOptions), "For args %v", args)
+		assert.Contains(t, out.String(), "my-bucket", "For args %v", args)
+		assert.Contains(t, out.String(), util.REDACTED, "For args %v", args)
+		assert.NotContains(t, out.String(), "render-config-test-access-key", "For args %v", args)
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"path/filepath"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+)
+
+// Run the given hooks, in order, with the given options. If there was already an error (e.g. Terraform failed), only
+// the hooks that are configured to run on error are run. Return the first error that happened, including the given
+// one; any later errors are logged.
+func runHooks(hooks []config.Hook, terragruntOptions *options.TerragruntOptions, previousErr error) error {
+	for _, hook := range hooks {
+		if previousErr != nil && !hook.RunOnError {
+			terragruntOptions.Logger.Printf("Skipping hook %s due to an earlier error", hook.Name)
+			continue
+		}
+
+		if err := runHook(hook, terragruntOptions); err != nil {
+			if previousErr == nil {
+				previousErr = err
+			} else {
+				terragruntOptions.Logger.Printf("ERROR: %s", err)
+			}
+		}
+	}
+
+	return previousErr
+}
+
+// Run the command of the given hook in the hook's working directory, which is relative to the working directory of
+// the given options
+func runHook(hook config.Hook, terragruntOptions *options.TerragruntOptions) error {
+	hookOptions := terragruntOptions.Clone()
+	if filepath.IsAbs(hook.WorkingDir) {
+		hookOptions.WorkingDir = hook.WorkingDir
+	} else {
+		hookOptions.WorkingDir = filepath.Join(terragruntOptions.WorkingDir, hook.WorkingDir)
+	}
+
+	terragruntOptions.Logger.Printf("Running hook %s", hook.Name)
+
+	if err := shell.RunShellCommandWithOptions(hookOptions, hook.Execute[0], hook.Execute[1:]...); err != nil {
+		return errors.WithStackTraceAndPrefix(err, "Error running hook %s", hook.Name)
+	}
+
+	return nil
+}
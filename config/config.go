@@ -1,34 +1,96 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"github.com/hashicorp/hcl"
 	"github.com/gruntwork-io/terragrunt/dynamodb"
 	"github.com/gruntwork-io/terragrunt/remote"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
 )
 
 const TERRAGRUNT_CONFIG_FILE = ".terragrunt"
 
+// Configs in this file are always parsed as JSON. Tools that generate configs may find JSON easier to write than HCL.
+const TERRAGRUNT_JSON_CONFIG_FILE = TERRAGRUNT_CONFIG_FILE + JSON_CONFIG_FILE_EXTENSION
+const JSON_CONFIG_FILE_EXTENSION = ".json"
+
 // A common interface with all fields that could be in the .terragrunt config file.
 type TerragruntConfig struct {
-	DynamoDbLock *dynamodb.DynamoDbLock
-	RemoteState  *remote.RemoteState
+	Include            *IncludeConfig
+	Terraform          *TerraformConfig
+	DynamoDbLock       *dynamodb.DynamoDbLock
+	RemoteState        *remote.RemoteState
+	StateBackup        *remote.StateBackup
+	Dependencies       []remote.Dependency `hcl:"dependency"`
+	ModuleDependencies *ModuleDependencies `hcl:"dependencies"`
+	ExtraArguments     []ExtraArguments    `hcl:"extraArguments"`
+	BeforeHooks        []Hook              `hcl:"beforeHook"`
+	AfterHooks         []Hook              `hcl:"afterHook"`
+	Environments       []Environment       `hcl:"environment"`
+	Redaction          *Redaction
+
+	// The versions of Terraform and Terragrunt this config works with, e.g. ">= 0.9.0, < 0.10.0". If set, Terragrunt
+	// exits with an error before running any command with a version that doesn't match.
+	TerraformVersionConstraint  string
+	TerragruntVersionConstraint string
+
+	// The Terraform binary to run instead of terraform from the PATH. A relative path such as ./bin/terraform is
+	// relative to the working directory.
+	TerraformBinary             string
+}
+
+// Another Terragrunt config file whose settings should be merged into this one
+type IncludeConfig struct {
+	Path string
+}
+
+// Settings for the Terraform templates Terragrunt runs
+type TerraformConfig struct {
+	// Where to download the Terraform templates from, e.g. git::https://github.com/foo/modules.git//app?ref=v1.2. If
+	// empty, Terraform runs on the templates in the working directory.
+	Source string
+}
+
+// Other folders with Terragrunt configs that must be applied before this one, e.g. by the apply-all command. The paths
+// are relative to the working directory. Folders referenced by dependency blocks don't need to be listed here.
+type ModuleDependencies struct {
+	Paths []string
+}
+
+// Return the path of the Terragrunt config file in the given folder: the .terragrunt file, or, if there isn't one but
+// there is a .terragrunt.json file, the .terragrunt.json file. If neither exists, return the path of the .terragrunt
+// file.
+func DefaultConfigPath(dir string) string {
+	hclPath := filepath.Join(dir, TERRAGRUNT_CONFIG_FILE)
+	jsonPath := filepath.Join(dir, TERRAGRUNT_JSON_CONFIG_FILE)
+
+	if !util.IsFile(hclPath) && util.IsFile(jsonPath) {
+		return jsonPath
+	}
+
+	return hclPath
 }
 
-// Read the Terragrunt config file from its default location
-func ReadTerragruntConfig() (*TerragruntConfig, error) {
-	return parseTerragruntConfigFile(TERRAGRUNT_CONFIG_FILE)
+// Return true if the given file name is the name of a Terragrunt config file
+func IsTerragruntConfigFile(fileName string) bool {
+	return fileName == TERRAGRUNT_CONFIG_FILE || fileName == TERRAGRUNT_JSON_CONFIG_FILE
 }
 
-// Parse the Terragrunt config file at the given path
-func parseTerragruntConfigFile(configPath string) (*TerragruntConfig, error) {
+// Read the Terragrunt config file at the path specified in the given options
+func ReadTerragruntConfig(terragruntOptions *options.TerragruntOptions) (*TerragruntConfig, error) {
+	configPath := terragruntOptions.TerragruntConfigPath
+
 	bytes, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, errors.WithStackTraceAndPrefix(err, "Error reading Terragrunt config file %s", configPath)
 	}
 
-	config, err := parseTerragruntConfig(string(bytes))
+	config, err := parseTerragruntConfig(string(bytes), terragruntOptions)
 	if err != nil {
 		return nil, errors.WithStackTraceAndPrefix(err, "Error parsing Terragrunt config file %s", configPath)
 	}
@@ -36,12 +98,64 @@ func parseTerragruntConfigFile(configPath string) (*TerragruntConfig, error) {
 	return config, nil
 }
 
-// Parse the Terragrunt config contained in the given string
-func parseTerragruntConfig(config string) (*TerragruntConfig, error) {
+// Return the absolute path of the Terragrunt config file that the Terragrunt config file at the path specified in the
+// given options includes, or an empty string if it doesn't include one
+func ReadIncludePath(terragruntOptions *options.TerragruntOptions) (string, error) {
+	configPath := terragruntOptions.TerragruntConfigPath
+
+	bytes, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return "", errors.WithStackTraceAndPrefix(err, "Error reading Terragrunt config file %s", configPath)
+	}
+
+	file, err := parseConfigSyntax(string(bytes), configPath)
+	if err != nil {
+		return "", err
+	}
+
 	terragruntConfig := &TerragruntConfig{}
+	if err := hcl.DecodeObject(terragruntConfig, file); err != nil {
+		return "", errors.WithStackTraceAndPrefix(err, "Error parsing Terragrunt config file %s", configPath)
+	}
 
-	if err := hcl.Decode(terragruntConfig, config); err != nil {
-		return nil, errors.WithStackTrace(err)
+	if terragruntConfig.Include == nil {
+		return "", nil
+	}
+
+	includePath, err := resolveIncludePath(terragruntConfig.Include, configPath, terragruntOptions)
+	if err != nil {
+		return "", err
+	}
+
+	absIncludePath, err := filepath.Abs(includePath)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return absIncludePath, nil
+}
+
+// Parse the Terragrunt config contained in the given string, which was read from the Terragrunt config file at the path
+// specified in the given options. If the config includes another config file, the two are merged, with the settings in
+// the given config taking precedence.
+func parseTerragruntConfig(config string, terragruntOptions *options.TerragruntOptions) (*TerragruntConfig, error) {
+	configPath := terragruntOptions.TerragruntConfigPath
+
+	terragruntConfig, includePath, err := decodeConfigWithIncludes(config, configPath, []string{}, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := terragruntConfig.selectEnvironment(terragruntOptions.Environment); err != nil {
+		return nil, err
+	}
+
+	if err := resolveConfigInterpolations(terragruntConfig, configPath, includePath, terragruntOptions); err != nil {
+		return nil, err
+	}
+
+	if err := terragruntConfig.validateVersionConstraints(); err != nil {
+		return nil, err
 	}
 
 	if terragruntConfig.DynamoDbLock != nil {
@@ -58,5 +172,479 @@ func parseTerragruntConfig(config string) (*TerragruntConfig, error) {
 		}
 	}
 
+	if terragruntConfig.Redaction != nil {
+		terragruntConfig.Redaction.FillDefaults()
+		if err := terragruntConfig.Redaction.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if terragruntConfig.StateBackup != nil {
+		terragruntConfig.StateBackup.FillDefaults()
+		if err := terragruntConfig.StateBackup.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if terragruntOptions.Environment != "" {
+		if err := terragruntConfig.namespaceForEnvironment(terragruntOptions.Environment); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range terragruntConfig.Dependencies {
+		terragruntConfig.Dependencies[i].FillDefaults()
+	}
+	if err := remote.ValidateDependencies(terragruntConfig.Dependencies); err != nil {
+		return nil, err
+	}
+
+	for i := range terragruntConfig.ExtraArguments {
+		terragruntConfig.ExtraArguments[i].FillDefaults()
+	}
+	if err := ValidateExtraArguments(terragruntConfig.ExtraArguments); err != nil {
+		return nil, err
+	}
+
+	for i := range terragruntConfig.BeforeHooks {
+		terragruntConfig.BeforeHooks[i].FillDefaults()
+	}
+	for i := range terragruntConfig.AfterHooks {
+		terragruntConfig.AfterHooks[i].FillDefaults()
+	}
+	if err := ValidateHooks(terragruntConfig.BeforeHooks); err != nil {
+		return nil, err
+	}
+	if err := ValidateHooks(terragruntConfig.AfterHooks); err != nil {
+		return nil, err
+	}
+
 	return terragruntConfig, nil
-}
\ No newline at end of file
+}
+
+// Decode the given config string, which was read from the config file at the given path, and if it includes another
+// config file, decode that one too (recursively) and merge the two. Return the merged config and the path of the config
+// file that was directly included, or an empty string if there was none. The given list of config paths that have
+// already been visited is used to detect include cycles.
+func decodeConfigWithIncludes(config string, configPath string, visitedPaths []string, terragruntOptions *options.TerragruntOptions) (*TerragruntConfig, string, error) {
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, "", errors.WithStackTrace(err)
+	}
+
+	for _, visitedPath := range visitedPaths {
+		if visitedPath == absConfigPath {
+			return nil, "", errors.WithStackTrace(IncludeCycle(strings.Join(append(visitedPaths, absConfigPath), " -> ")))
+		}
+	}
+	visitedPaths = append(visitedPaths, absConfigPath)
+
+	file, err := parseConfigSyntax(config, configPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := validateConfigStructure(file, configPath); err != nil {
+		return nil, "", err
+	}
+
+	terragruntConfig := &TerragruntConfig{}
+	if err := hcl.DecodeObject(terragruntConfig, file); err != nil {
+		return nil, "", errors.WithStackTrace(err)
+	}
+
+	if terragruntConfig.Include == nil {
+		return terragruntConfig, "", nil
+	}
+
+	includePath, err := resolveIncludePath(terragruntConfig.Include, configPath, terragruntOptions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	includedBytes, err := ioutil.ReadFile(includePath)
+	if err != nil {
+		return nil, "", errors.WithStackTraceAndPrefix(err, "Error reading Terragrunt config file %s included from %s", includePath, configPath)
+	}
+
+	includedConfig, _, err := decodeConfigWithIncludes(string(includedBytes), includePath, visitedPaths, terragruntOptions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return mergeConfigs(includedConfig, terragruntConfig), includePath, nil
+}
+
+// Return the path of the config file to include. The path may use interpolations such as ${find_in_parent_folders()},
+// and if it is relative, it is relative to the folder of the config file that includes it.
+func resolveIncludePath(include *IncludeConfig, configPath string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	if include.Path == "" {
+		return "", errors.WithStackTrace(IncludePathMissing)
+	}
+
+	context := newInterpolationContext(configPath, "", "", terragruntOptions)
+	includePath, err := context.resolveInterpolations(include.Path)
+	if err != nil {
+		return "", errors.WithStackTraceAndPrefix(err, "Error in the value of include.path")
+	}
+
+	if filepath.IsAbs(includePath) {
+		return includePath, nil
+	}
+
+	return filepath.Join(filepath.Dir(configPath), includePath), nil
+}
+
+// Merge the given included config and the config that includes it. Each of the top-level blocks (terraform,
+// dynamoDbLock, remoteState, stateBackup, dependencies, and redaction) in the including config replaces the whole
+// block of the same name in the included config; the blocks are not merged field by field. The version constraints
+// and the Terraform binary in the including config, if set, replace those in the included config. Dependencies,
+// extraArguments blocks, hooks, and environments are merged by name, with those in the including config taking
+// precedence.
+func mergeConfigs(includedConfig *TerragruntConfig, includingConfig *TerragruntConfig) *TerragruntConfig {
+	merged := *includedConfig
+	merged.Include = nil
+
+	if includingConfig.Terraform != nil {
+		merged.Terraform = includingConfig.Terraform
+	}
+
+	if includingConfig.DynamoDbLock != nil {
+		merged.DynamoDbLock = includingConfig.DynamoDbLock
+	}
+
+	if includingConfig.RemoteState != nil {
+		merged.RemoteState = includingConfig.RemoteState
+	}
+
+	if includingConfig.StateBackup != nil {
+		merged.StateBackup = includingConfig.StateBackup
+	}
+
+	if includingConfig.ModuleDependencies != nil {
+		merged.ModuleDependencies = includingConfig.ModuleDependencies
+	}
+
+	if includingConfig.Redaction != nil {
+		merged.Redaction = includingConfig.Redaction
+	}
+
+	if includingConfig.TerraformVersionConstraint != "" {
+		merged.TerraformVersionConstraint = includingConfig.TerraformVersionConstraint
+	}
+
+	if includingConfig.TerragruntVersionConstraint != "" {
+		merged.TerragruntVersionConstraint = includingConfig.TerragruntVersionConstraint
+	}
+
+	if includingConfig.TerraformBinary != "" {
+		merged.TerraformBinary = includingConfig.TerraformBinary
+	}
+
+	merged.Dependencies = mergeDependencies(includedConfig.Dependencies, includingConfig.Dependencies)
+	merged.ExtraArguments = mergeExtraArguments(includedConfig.ExtraArguments, includingConfig.ExtraArguments)
+	merged.BeforeHooks = mergeHooks(includedConfig.BeforeHooks, includingConfig.BeforeHooks)
+	merged.AfterHooks = mergeHooks(includedConfig.AfterHooks, includingConfig.AfterHooks)
+	merged.Environments = mergeEnvironments(includedConfig.Environments, includingConfig.Environments)
+
+	return &merged
+}
+
+// Merge the given lists of dependencies. If a dependency with the same name is in both lists, the one from the
+// overriding list is used.
+func mergeDependencies(dependencies []remote.Dependency, overrides []remote.Dependency) []remote.Dependency {
+	merged := []remote.Dependency{}
+
+	for _, dependency := range dependencies {
+		if !containsDependency(overrides, dependency.Name) {
+			merged = append(merged, dependency)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// Return true if the given list contains a dependency with the given name
+func containsDependency(dependencies []remote.Dependency, name string) bool {
+	for _, dependency := range dependencies {
+		if dependency.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Merge the given lists of extra arguments blocks. If a block with the same name is in both lists, the one from the
+// overriding list is used.
+func mergeExtraArguments(extraArgsList []ExtraArguments, overrides []ExtraArguments) []ExtraArguments {
+	merged := []ExtraArguments{}
+
+	for _, extraArgs := range extraArgsList {
+		if !containsExtraArguments(overrides, extraArgs.Name) {
+			merged = append(merged, extraArgs)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// Return true if the given list contains an extra arguments block with the given name
+func containsExtraArguments(extraArgsList []ExtraArguments, name string) bool {
+	for _, extraArgs := range extraArgsList {
+		if extraArgs.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Merge the given lists of hooks. If a hook with the same name is in both lists, the one from the overriding list is
+// used.
+func mergeHooks(hooks []Hook, overrides []Hook) []Hook {
+	merged := []Hook{}
+
+	for _, hook := range hooks {
+		if !containsHook(overrides, hook.Name) {
+			merged = append(merged, hook)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// Return true if the given list contains a hook with the given name
+func containsHook(hooks []Hook, name string) bool {
+	for _, hook := range hooks {
+		if hook.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Merge the given lists of environments. If an environment with the same name is in both lists, the one from the
+// overriding list is used.
+func mergeEnvironments(environments []Environment, overrides []Environment) []Environment {
+	merged := []Environment{}
+
+	for _, environment := range environments {
+		if !containsEnvironment(overrides, environment.Name) {
+			merged = append(merged, environment)
+		}
+	}
+
+	return append(merged, overrides...)
+}
+
+// Return true if the given list contains an environment with the given name
+func containsEnvironment(environments []Environment, name string) bool {
+	for _, environment := range environments {
+		if environment.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Replace the interpolations, such as ${path_relative_to_root()}, in the Terraform source, the stateFileId of the
+// DynamoDB lock settings, the backendConfigs of the remote state settings, the paths of the dependencies block, and the
+// extraArguments blocks and hooks of the given config with their values. Interpolations are always
+// evaluated relative to the config at the given path, even if they were defined in the config it includes.
+func resolveConfigInterpolations(terragruntConfig *TerragruntConfig, configPath string, includePath string, terragruntOptions *options.TerragruntOptions) error {
+	stateFileId := ""
+
+	if terragruntConfig.Terraform != nil {
+		context := newInterpolationContext(configPath, includePath, "", terragruntOptions)
+
+		resolvedSource, err := context.resolveInterpolations(terragruntConfig.Terraform.Source)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the value of terraform.source")
+		}
+
+		terragruntConfig.Terraform.Source = resolvedSource
+	}
+
+	if terragruntConfig.DynamoDbLock != nil {
+		context := newInterpolationContext(configPath, includePath, "", terragruntOptions)
+
+		resolvedStateFileId, err := context.resolveInterpolations(terragruntConfig.DynamoDbLock.StateFileId)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the value of dynamoDbLock.stateFileId")
+		}
+
+		terragruntConfig.DynamoDbLock.StateFileId = resolvedStateFileId
+		stateFileId = resolvedStateFileId
+	}
+
+	if terragruntConfig.RemoteState != nil {
+		context := newInterpolationContext(configPath, includePath, stateFileId, terragruntOptions)
+
+		for key, value := range terragruntConfig.RemoteState.BackendConfigs {
+			resolvedValue, err := context.resolveInterpolations(value)
+			if err != nil {
+				return errors.WithStackTraceAndPrefix(err, "Error in the value of backendConfigs.%s", key)
+			}
+			terragruntConfig.RemoteState.BackendConfigs[key] = resolvedValue
+		}
+	}
+
+	context := newInterpolationContext(configPath, includePath, stateFileId, terragruntOptions)
+
+	if terragruntConfig.ModuleDependencies != nil {
+		for i, path := range terragruntConfig.ModuleDependencies.Paths {
+			resolvedPath, err := context.resolveInterpolations(path)
+			if err != nil {
+				return errors.WithStackTraceAndPrefix(err, "Error in the paths of dependencies")
+			}
+			terragruntConfig.ModuleDependencies.Paths[i] = resolvedPath
+		}
+	}
+
+	for i := range terragruntConfig.ExtraArguments {
+		if err := resolveExtraArgumentsInterpolations(&terragruntConfig.ExtraArguments[i], context); err != nil {
+			return err
+		}
+	}
+
+	for i := range terragruntConfig.BeforeHooks {
+		if err := resolveHookInterpolations(&terragruntConfig.BeforeHooks[i], context); err != nil {
+			return err
+		}
+	}
+
+	for i := range terragruntConfig.AfterHooks {
+		if err := resolveHookInterpolations(&terragruntConfig.AfterHooks[i], context); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return the paths of all the folders this config depends on, as declared in the dependencies block and in dependency
+// blocks, without duplicates
+func (terragruntConfig *TerragruntConfig) DependencyPaths() []string {
+	paths := []string{}
+	seen := map[string]bool{}
+
+	addPath := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	if terragruntConfig.ModuleDependencies != nil {
+		for _, path := range terragruntConfig.ModuleDependencies.Paths {
+			addPath(path)
+		}
+	}
+
+	for _, dependency := range terragruntConfig.Dependencies {
+		addPath(dependency.Path)
+	}
+
+	return paths
+}
+
+// Replace the interpolations in the arguments, environment variables, and optional var files of the given extra
+// arguments block with their values
+func resolveExtraArgumentsInterpolations(extraArgs *ExtraArguments, context interpolationContext) error {
+	for i, arg := range extraArgs.Arguments {
+		resolvedArg, err := context.resolveInterpolations(arg)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the arguments of extraArguments %s", extraArgs.Name)
+		}
+		extraArgs.Arguments[i] = resolvedArg
+	}
+
+	for name, value := range extraArgs.EnvVars {
+		resolvedValue, err := context.resolveInterpolations(value)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the value of envVars.%s of extraArguments %s", name, extraArgs.Name)
+		}
+		extraArgs.EnvVars[name] = resolvedValue
+	}
+
+	for i, varFile := range extraArgs.OptionalVarFiles {
+		resolvedVarFile, err := context.resolveInterpolations(varFile)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the optionalVarFiles of extraArguments %s", extraArgs.Name)
+		}
+		extraArgs.OptionalVarFiles[i] = resolvedVarFile
+	}
+
+	return nil
+}
+
+// Replace the interpolations in the command and working directory of the given hook with their values
+func resolveHookInterpolations(hook *Hook, context interpolationContext) error {
+	for i, arg := range hook.Execute {
+		resolvedArg, err := context.resolveInterpolations(arg)
+		if err != nil {
+			return errors.WithStackTraceAndPrefix(err, "Error in the execute parameter of hook %s", hook.Name)
+		}
+		hook.Execute[i] = resolvedArg
+	}
+
+	resolvedWorkingDir, err := context.resolveInterpolations(hook.WorkingDir)
+	if err != nil {
+		return errors.WithStackTraceAndPrefix(err, "Error in the workingDir parameter of hook %s", hook.Name)
+	}
+	hook.WorkingDir = resolvedWorkingDir
+
+	return nil
+}
+
+// Make the relative paths in this config that are relative to the working directory (the paths of dependency blocks and
+// of the dependencies block, the optional var files of extraArguments blocks, the working directories of hooks, and
+// the state backup dir) absolute, using the given working directory. This way, they still point to the same place when
+// Terraform runs in a different folder, such as the folder a Terraform source was downloaded to.
+func (terragruntConfig *TerragruntConfig) MakePathsAbsolute(workingDir string) error {
+	absWorkingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	for i := range terragruntConfig.Dependencies {
+		terragruntConfig.Dependencies[i].Path = joinWithWorkingDir(absWorkingDir, terragruntConfig.Dependencies[i].Path)
+	}
+
+	if terragruntConfig.ModuleDependencies != nil {
+		for i, path := range terragruntConfig.ModuleDependencies.Paths {
+			terragruntConfig.ModuleDependencies.Paths[i] = joinWithWorkingDir(absWorkingDir, path)
+		}
+	}
+
+	for i := range terragruntConfig.ExtraArguments {
+		for j, varFile := range terragruntConfig.ExtraArguments[i].OptionalVarFiles {
+			terragruntConfig.ExtraArguments[i].OptionalVarFiles[j] = joinWithWorkingDir(absWorkingDir, varFile)
+		}
+	}
+
+	for _, hooks := range [][]Hook{terragruntConfig.BeforeHooks, terragruntConfig.AfterHooks} {
+		for i := range hooks {
+			if hooks[i].WorkingDir != "" {
+				hooks[i].WorkingDir = joinWithWorkingDir(absWorkingDir, hooks[i].WorkingDir)
+			}
+		}
+	}
+
+	if terragruntConfig.StateBackup != nil {
+		terragruntConfig.StateBackup.BackupDir = joinWithWorkingDir(absWorkingDir, terragruntConfig.StateBackup.BackupDir)
+	}
+
+	return nil
+}
+
+var IncludePathMissing = fmt.Errorf("The path parameter must be specified for include")
+
+type IncludeCycle string
+
+func (paths IncludeCycle) Error() string {
+	return fmt.Sprintf("Found a cycle in the Terragrunt config files included from one another: %s", string(paths))
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/gruntwork-io/terragrunt/dynamodb"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches interpolations such as ${path_relative_to_root()} in the values of the Terragrunt config
+var INTERPOLATION_SYNTAX_REGEX = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Matches a call to a helper function, such as get_env("NAME", "default")
+var HELPER_FUNCTION_SYNTAX_REGEX = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\((.*)\)\s*$`)
+
+// Matches a reference to a variable, such as stateFileId
+var VARIABLE_SYNTAX_REGEX = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*$`)
+
+// Matches the arguments of get_env: a quoted name and, optionally, a quoted default value
+var GET_ENV_ARGS_REGEX = regexp.MustCompile(`^\s*"([^"]*)"\s*(?:,\s*"([^"]*)"\s*)?$`)
+
+// Everything the interpolation functions need to know about the Terragrunt config they are being evaluated in
+type interpolationContext struct {
+	// The path to the Terragrunt config file
+	configPath string
+	// The path to the Terragrunt config file included by the config file, or an empty string if there is none
+	includePath string
+	// The stateFileId from the dynamoDbLock settings, or an empty string if locking is not configured
+	stateFileId string
+	// Used to look up environment variables
+	getEnv func(string) string
+	// Used to look up the id of the AWS account for the current AWS credentials
+	getAccountId func() (string, error)
+	// Used to decrypt a ciphertext with the given provider, such as kms
+	decryptCiphertext func(provider string, ciphertext []byte) ([]byte, error)
+	// Used to register a decrypted value as a secret, so it's redacted from everything Terragrunt logs
+	registerSecret func(value string)
+}
+
+// Return an interpolation context for the Terragrunt config at the given path that looks up environment variables in
+// the given options and AWS accounts, decrypts values for real, and registers decrypted values with the global redactor
+func newInterpolationContext(configPath string, includePath string, stateFileId string, terragruntOptions *options.TerragruntOptions) interpolationContext {
+	return interpolationContext{
+		configPath:   configPath,
+		includePath:  includePath,
+		stateFileId:  stateFileId,
+		getEnv:       terragruntOptions.Getenv,
+		getAccountId: getAwsAccountId,
+		decryptCiphertext: func(provider string, ciphertext []byte) ([]byte, error) {
+			return decryptCiphertext(provider, ciphertext, terragruntOptions)
+		},
+		registerSecret: util.RegisterSecret,
+	}
+}
+
+// Replace all the interpolations, such as ${path_relative_to_root()}, in the given string with their values
+func (context interpolationContext) resolveInterpolations(str string) (string, error) {
+	var resolveErr error
+
+	resolved := INTERPOLATION_SYNTAX_REGEX.ReplaceAllStringFunc(str, func(interpolation string) string {
+		if resolveErr != nil {
+			return interpolation
+		}
+
+		expression := INTERPOLATION_SYNTAX_REGEX.FindStringSubmatch(interpolation)[1]
+		value, err := context.evaluateExpression(expression)
+		if err != nil {
+			resolveErr = err
+			return interpolation
+		}
+
+		return value
+	})
+
+	return resolved, resolveErr
+}
+
+// Evaluate the given expression, which is the part of an interpolation between ${ and }
+func (context interpolationContext) evaluateExpression(expression string) (string, error) {
+	if matches := HELPER_FUNCTION_SYNTAX_REGEX.FindStringSubmatch(expression); matches != nil {
+		return context.executeHelperFunction(matches[1], matches[2])
+	}
+
+	if matches := VARIABLE_SYNTAX_REGEX.FindStringSubmatch(expression); matches != nil {
+		return context.lookupVariable(matches[1])
+	}
+
+	return "", errors.WithStackTrace(InvalidInterpolationSyntax(expression))
+}
+
+// Execute the helper function with the given name and the given (unparsed) arguments
+func (context interpolationContext) executeHelperFunction(functionName string, args string) (string, error) {
+	switch functionName {
+	case "path_relative_to_root":
+		if err := expectNoArgs(functionName, args); err != nil {
+			return "", err
+		}
+		return pathRelativeToRoot(context.configPath)
+	case "path_relative_to_include":
+		if err := expectNoArgs(functionName, args); err != nil {
+			return "", err
+		}
+		return pathRelativeToInclude(context.configPath, context.includePath)
+	case "find_in_parent_folders":
+		if err := expectNoArgs(functionName, args); err != nil {
+			return "", err
+		}
+		return findInParentFolders(context.configPath)
+	case "get_env":
+		return context.getEnvWithDefault(args)
+	case "aws_account_id":
+		if err := expectNoArgs(functionName, args); err != nil {
+			return "", err
+		}
+		return context.getAccountId()
+	case "decrypt":
+		return context.decryptValue(args)
+	default:
+		return "", errors.WithStackTrace(UnknownHelperFunction(functionName))
+	}
+}
+
+// Return the value of the variable with the given name
+func (context interpolationContext) lookupVariable(name string) (string, error) {
+	switch name {
+	case "stateFileId":
+		if context.stateFileId == "" {
+			return "", errors.WithStackTrace(StateFileIdNotAvailable)
+		}
+		return context.stateFileId, nil
+	default:
+		return "", errors.WithStackTrace(UnknownVariable(name))
+	}
+}
+
+// Return an error if the given (unparsed) arguments to the function with the given name are not empty
+func expectNoArgs(functionName string, args string) error {
+	if strings.TrimSpace(args) != "" {
+		return errors.WithStackTrace(InvalidFunctionArgs{FunctionName: functionName, Args: args})
+	}
+
+	return nil
+}
+
+// Return the value of the environment variable named in the given (unparsed) arguments, which are of the form
+// "NAME" or "NAME", "default". If the environment variable is not set, return the default value, if there is one, or
+// an empty string.
+func (context interpolationContext) getEnvWithDefault(args string) (string, error) {
+	matches := GET_ENV_ARGS_REGEX.FindStringSubmatch(args)
+	if matches == nil {
+		return "", errors.WithStackTrace(InvalidFunctionArgs{FunctionName: "get_env", Args: args})
+	}
+
+	value := context.getEnv(matches[1])
+	if value == "" {
+		return matches[2], nil
+	}
+
+	return value, nil
+}
+
+// Return the path of the folder that contains the given Terragrunt config file, relative to the root of the Git
+// repository that contains it. Forward slashes are used on all operating systems so the result can be used in S3 keys.
+func pathRelativeToRoot(configPath string) (string, error) {
+	configDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = configDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStackTrace(NotInGitRepo(configDir))
+	}
+
+	rootDir, err := filepath.EvalSymlinks(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	configDir, err = filepath.EvalSymlinks(configDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	relativePath, err := filepath.Rel(rootDir, configDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return filepath.ToSlash(relativePath), nil
+}
+
+// Return the path of the folder that contains the given Terragrunt config file, relative to the folder that contains
+// the config file it includes. If the config doesn't include another config, return ".".
+func pathRelativeToInclude(configPath string, includePath string) (string, error) {
+	if includePath == "" {
+		return ".", nil
+	}
+
+	includeDir, err := filepath.Abs(filepath.Dir(includePath))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	relativePath, err := filepath.Rel(includeDir, configDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return filepath.ToSlash(relativePath), nil
+}
+
+// Find a Terragrunt config file in the parent folders of the folder that contains the given Terragrunt config file,
+// starting with the closest one, and return its path relative to that folder
+func findInParentFolders(configPath string) (string, error) {
+	configDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	currentDir := configDir
+	for {
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return "", errors.WithStackTrace(ParentTerragruntConfigNotFound(configDir))
+		}
+
+		candidate := DefaultConfigPath(parentDir)
+		if util.IsFile(candidate) {
+			relativePath, err := filepath.Rel(configDir, candidate)
+			if err != nil {
+				return "", errors.WithStackTrace(err)
+			}
+			return relativePath, nil
+		}
+
+		currentDir = parentDir
+	}
+}
+
+// Return the id of the AWS account for the current AWS credentials
+func getAwsAccountId() (string, error) {
+	config := defaults.Get().Config.WithRegion(dynamodb.DEFAULT_AWS_REGION)
+	if _, err := config.Credentials.Get(); err != nil {
+		return "", errors.WithStackTraceAndPrefix(err, "Error finding AWS credentials (did you set the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables?)")
+	}
+
+	output, err := sts.New(session.New(), config).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return *output.Account, nil
+}
+
+var StateFileIdNotAvailable = fmt.Errorf("The ${stateFileId} variable can only be used if the dynamoDbLock settings specify a stateFileId")
+
+type UnknownHelperFunction string
+
+func (functionName UnknownHelperFunction) Error() string {
+	return fmt.Sprintf("Unknown helper function: %s. Supported functions are path_relative_to_root(), path_relative_to_include(), find_in_parent_folders(), get_env(\"NAME\", \"default\"), aws_account_id(), and decrypt(\"ENC[provider,ciphertext]\").", string(functionName))
+}
+
+type UnknownVariable string
+
+func (name UnknownVariable) Error() string {
+	return fmt.Sprintf("Unknown variable: %s. The only supported variable is stateFileId.", string(name))
+}
+
+type InvalidInterpolationSyntax string
+
+func (expression InvalidInterpolationSyntax) Error() string {
+	return fmt.Sprintf("Invalid interpolation syntax: ${%s}", string(expression))
+}
+
+type InvalidFunctionArgs struct {
+	FunctionName string
+	Args         string
+}
+
+func (err InvalidFunctionArgs) Error() string {
+	return fmt.Sprintf("Invalid arguments for helper function %s: (%s)", err.FunctionName, err.Args)
+}
+
+type ParentTerragruntConfigNotFound string
+
+func (dir ParentTerragruntConfigNotFound) Error() string {
+	return fmt.Sprintf("Could not find a %s or %s file in any of the parent folders of %s", TERRAGRUNT_CONFIG_FILE, TERRAGRUNT_JSON_CONFIG_FILE, string(dir))
+}
+
+type NotInGitRepo string
+
+func (dir NotInGitRepo) Error() string {
+	return fmt.Sprintf("The path_relative_to_root() function requires the Terragrunt config to be in a Git repository, but %s is not", string(dir))
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+)
+
+func TestResolveInterpolations(t *testing.T) {
+	t.Parallel()
+
+	context := mockInterpolationContext("my-app", map[string]string{"TF_ENV": "stage"})
+
+	testCases := []struct {
+		str      string
+		expected string
+	}{
+		{"", ""},
+		{"terraform.tfstate", "terraform.tfstate"},
+		{"${stateFileId}/terraform.tfstate", "my-app/terraform.tfstate"},
+		{"${ stateFileId }", "my-app"},
+		{`${get_env("TF_ENV", "dev")}/terraform.tfstate`, "stage/terraform.tfstate"},
+		{`${get_env("NOT_SET", "dev")}/terraform.tfstate`, "dev/terraform.tfstate"},
+		{`${get_env("NOT_SET")}`, ""},
+		{`${get_env( "TF_ENV" )}`, "stage"},
+		{"terragrunt-state-${aws_account_id()}", "terragrunt-state-123456789012"},
+		{`${aws_account_id()}/${get_env("TF_ENV", "dev")}/${stateFileId}`, "123456789012/stage/my-app"},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := context.resolveInterpolations(testCase.str)
+		assert.Nil(t, err, "For string %s: %v", testCase.str, err)
+		assert.Equal(t, testCase.expected, actual, "For string %s", testCase.str)
+	}
+}
+
+func TestResolveInterpolationsErrors(t *testing.T) {
+	t.Parallel()
+
+	context := mockInterpolationContext("", map[string]string{})
+
+	testCases := []struct {
+		str         string
+		expectedErr error
+	}{
+		{"${unknown_function()}", UnknownHelperFunction("unknown_function")},
+		{"${unknownVariable}", UnknownVariable("unknownVariable")},
+		{"${stateFileId}", StateFileIdNotAvailable},
+		{"${get_env(NAME)}", InvalidFunctionArgs{FunctionName: "get_env", Args: "NAME"}},
+		{`${aws_account_id("foo")}`, InvalidFunctionArgs{FunctionName: "aws_account_id", Args: `"foo"`}},
+		{"${1 + 1}", InvalidInterpolationSyntax("1 + 1")},
+	}
+
+	for _, testCase := range testCases {
+		_, err := context.resolveInterpolations(testCase.str)
+		assert.True(t, errors.IsError(err, testCase.expectedErr), "For string %s, unexpected error of type %s: %s", testCase.str, reflect.TypeOf(err), err)
+	}
+}
+
+func TestPathRelativeToRoot(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	childDir := filepath.Join(rootDir, "stage", "vpc")
+	if err := os.MkdirAll(childDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := pathRelativeToRoot(filepath.Join(childDir, TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, NotInGitRepo(childDir)), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = rootDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %s\n%s", err, out)
+	}
+
+	relativePath, err := pathRelativeToRoot(filepath.Join(childDir, TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, "stage/vpc", relativePath)
+
+	relativePath, err = pathRelativeToRoot(filepath.Join(rootDir, TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, ".", relativePath)
+}
+
+func TestPathRelativeToInclude(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		configPath  string
+		includePath string
+		expected    string
+	}{
+		{"/root/stage/mysql/.terragrunt", "", "."},
+		{"/root/stage/mysql/.terragrunt", "/root/.terragrunt", "stage/mysql"},
+		{"/root/.terragrunt", "/root/.terragrunt", "."},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := pathRelativeToInclude(testCase.configPath, testCase.includePath)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, actual, "For config path %s and include path %s", testCase.configPath, testCase.includePath)
+	}
+}
+
+func mockInterpolationContext(stateFileId string, env map[string]string) interpolationContext {
+	return interpolationContext{
+		configPath: TERRAGRUNT_CONFIG_FILE,
+		stateFileId: stateFileId,
+		getEnv: func(name string) string { return env[name] },
+		getAccountId: func() (string, error) { return "123456789012", nil },
+		decryptCiphertext: func(provider string, ciphertext []byte) ([]byte, error) {
+			return []byte(provider + ":" + string(ciphertext) + "\n"), nil
+		},
+		registerSecret: func(value string) {},
+	}
+}
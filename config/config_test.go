@@ -6,7 +6,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/gruntwork-io/terragrunt/remote"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
 	"reflect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 )
 
 func TestParseTerragruntConfigDynamoLockMinimalConfig(t *testing.T) {
@@ -19,7 +23,7 @@ func TestParseTerragruntConfigDynamoLockMinimalConfig(t *testing.T) {
 	}
 	`
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.Nil(t, terragruntConfig.RemoteState)
@@ -43,7 +47,7 @@ func TestParseTerragruntConfigDynamoLockFullConfig(t *testing.T) {
 	}
 	`
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.Nil(t, terragruntConfig.RemoteState)
@@ -63,7 +67,7 @@ func TestParseTerragruntConfigDynamoLockMissingStateFileId(t *testing.T) {
 	}
 	`
 
-	_, err := parseTerragruntConfig(config)
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.True(t, errors.IsError(err, dynamodb.StateFileIdMissing), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
 }
 
@@ -77,7 +81,7 @@ func TestParseTerragruntConfigRemoteStateMinimalConfig(t *testing.T) {
 	}
 	`
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.Nil(t, terragruntConfig.DynamoDbLock)
@@ -95,7 +99,7 @@ func TestParseTerragruntConfigRemoteStateMissingBackend(t *testing.T) {
 	}
 	`
 
-	_, err := parseTerragruntConfig(config)
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.True(t, errors.IsError(err, remote.RemoteBackendMissing), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
 }
 
@@ -116,7 +120,7 @@ func TestParseTerragruntConfigRemoteStateFullConfig(t *testing.T) {
 	}
 	`
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.Nil(t, terragruntConfig.DynamoDbLock)
@@ -152,7 +156,7 @@ func TestParseTerragruntConfigRemoteStateAndDynamoDbFullConfig(t *testing.T) {
 	}
 	`
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.NotNil(t, terragruntConfig.DynamoDbLock)
@@ -175,9 +179,752 @@ func TestParseTerragruntConfigEmptyConfig(t *testing.T) {
 
 	config := ``
 
-	terragruntConfig, err := parseTerragruntConfig(config)
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
 	assert.Nil(t, err)
 
 	assert.Nil(t, terragruntConfig.RemoteState)
 	assert.Nil(t, terragruntConfig.DynamoDbLock)
-}
\ No newline at end of file
+}
+
+func TestParseTerragruntConfigStateBackupMinimalConfig(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	stateBackup = {
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.StateBackup)
+	assert.Equal(t, remote.DEFAULT_STATE_BACKUP_DIR, terragruntConfig.StateBackup.BackupDir)
+	assert.Equal(t, remote.DEFAULT_MAX_STATE_BACKUPS, terragruntConfig.StateBackup.MaxBackups)
+}
+
+func TestParseTerragruntConfigStateBackupFullConfig(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	stateBackup = {
+	  backupDir = "/tmp/state-backups"
+	  maxBackups = 3
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.StateBackup)
+	assert.Equal(t, "/tmp/state-backups", terragruntConfig.StateBackup.BackupDir)
+	assert.Equal(t, 3, terragruntConfig.StateBackup.MaxBackups)
+}
+
+func TestParseTerragruntConfigDependencies(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+
+	dependency "mysql" {
+	  path = "../data-stores/mysql"
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []remote.Dependency{
+		remote.Dependency{Name: "vpc", Path: "../vpc"},
+		remote.Dependency{Name: "mysql", Path: "../data-stores/mysql"},
+	}, terragruntConfig.Dependencies)
+}
+
+func TestParseTerragruntConfigDependencyMissingPath(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dependency "vpc" {
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, remote.DependencyPathMissing("vpc")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigDuplicateDependencyName(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+
+	dependency "vpc" {
+	  path = "../other-vpc"
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, remote.DuplicateDependencyName("vpc")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigRemoteStateInterpolation(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dynamoDbLock = {
+	  stateFileId = "my-app"
+	}
+
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    bucket = "my-bucket"
+	    key = "${stateFileId}/${get_env("TERRAGRUNT_TEST_ENV_VAR_NOT_SET", "dev")}/terraform.tfstate"
+	  }
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.RemoteState)
+	assert.Equal(t, "my-bucket", terragruntConfig.RemoteState.BackendConfigs["bucket"])
+	assert.Equal(t, "my-app/dev/terraform.tfstate", terragruntConfig.RemoteState.BackendConfigs["key"])
+}
+
+func TestParseTerragruntConfigRemoteStateUnknownFunction(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    key = "${path_relative_to_nowhere()}/terraform.tfstate"
+	  }
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, UnknownHelperFunction("path_relative_to_nowhere")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigIncludeFromParentFolder(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	dynamoDbLock = {
+	  stateFileId = "${path_relative_to_include()}"
+	}
+
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    bucket = "my-bucket"
+	    key = "${path_relative_to_include()}/terraform.tfstate"
+	  }
+	}
+
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "stage", "mysql"),
+	`
+	include = {
+	  path = "${find_in_parent_folders()}"
+	}
+
+	stateBackup = {
+	  maxBackups = 3
+	}
+
+	dependency "vpc" {
+	  path = "../../vpc"
+	}
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+
+	assert.Nil(t, terragruntConfig.Include)
+	assert.NotNil(t, terragruntConfig.DynamoDbLock)
+	assert.Equal(t, "stage/mysql", terragruntConfig.DynamoDbLock.StateFileId)
+	assert.NotNil(t, terragruntConfig.RemoteState)
+	assert.Equal(t, "my-bucket", terragruntConfig.RemoteState.BackendConfigs["bucket"])
+	assert.Equal(t, "stage/mysql/terraform.tfstate", terragruntConfig.RemoteState.BackendConfigs["key"])
+	assert.NotNil(t, terragruntConfig.StateBackup)
+	assert.Equal(t, 3, terragruntConfig.StateBackup.MaxBackups)
+	assert.Equal(t, []remote.Dependency{remote.Dependency{Name: "vpc", Path: "../../vpc"}}, terragruntConfig.Dependencies)
+}
+
+func TestParseTerragruntConfigIncludeOverridesWholeBlocks(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    bucket = "my-bucket"
+	    encrypt = "true"
+	  }
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "child"),
+	`
+	include = {
+	  path = "../.terragrunt"
+	}
+
+	remoteState = {
+	  backend = "consul"
+	  backendConfigs = {
+	    path = "child"
+	  }
+	}
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.RemoteState)
+	assert.Equal(t, "consul", terragruntConfig.RemoteState.Backend)
+	assert.Equal(t, map[string]string{"path": "child"}, terragruntConfig.RemoteState.BackendConfigs)
+}
+
+func TestParseTerragruntConfigIncludeCycle(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, filepath.Join(rootDir, "a"), `include = { path = "../b/.terragrunt" }`)
+	configPath := writeConfigFile(t, filepath.Join(rootDir, "b"), `include = { path = "../a/.terragrunt" }`)
+
+	_, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(configPath))
+	assert.NotNil(t, err)
+	assert.IsType(t, IncludeCycle(""), errors.Unwrap(err))
+}
+
+func TestParseTerragruntConfigIncludeNotFound(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	configPath := writeConfigFile(t, rootDir, `include = { path = "${find_in_parent_folders()}" }`)
+
+	_, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(configPath))
+	assert.NotNil(t, err)
+	assert.IsType(t, ParentTerragruntConfigNotFound(""), errors.Unwrap(err))
+}
+
+func TestParseTerragruntConfigIncludeIgnoresFoldersNamedLikeConfigs(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	// Like the folder Terragrunt keeps its own files in, in the home directory
+	if err := os.MkdirAll(filepath.Join(rootDir, TERRAGRUNT_CONFIG_FILE), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := writeConfigFile(t, filepath.Join(rootDir, "app"), `include = { path = "${find_in_parent_folders()}" }`)
+
+	_, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(configPath))
+	assert.NotNil(t, err)
+	assert.IsType(t, ParentTerragruntConfigNotFound(""), errors.Unwrap(err))
+}
+
+func TestParseTerragruntConfigIncludeMissingPath(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	include = {
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, IncludePathMissing), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigExtraArguments(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	extraArguments "vars" {
+	  commands = ["plan", "apply"]
+	  arguments = ["-var-file=common.tfvars", "-var", "env=${get_env("TF_ENV", "dev")}"]
+	  envVars = {
+	    TF_LOG = "DEBUG"
+	  }
+	  optionalVarFiles = ["${get_env("TF_ENV", "dev")}.tfvars"]
+	}
+
+	extraArguments "no_lock" {
+	  commands = ["plan"]
+	  arguments = ["-lock=false"]
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []ExtraArguments{
+		{
+			Name: "vars",
+			Commands: []string{"plan", "apply"},
+			Arguments: []string{"-var-file=common.tfvars", "-var", "env=dev"},
+			EnvVars: map[string]string{"TF_LOG": "DEBUG"},
+			OptionalVarFiles: []string{"dev.tfvars"},
+		},
+		{
+			Name: "no_lock",
+			Commands: []string{"plan"},
+			Arguments: []string{"-lock=false"},
+		},
+	}, terragruntConfig.ExtraArguments)
+}
+
+func TestParseTerragruntConfigExtraArgumentsMissingCommands(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	extraArguments "vars" {
+	  arguments = ["-var-file=common.tfvars"]
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, ExtraArgumentsCommandsMissing("vars")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigIncludeMergesExtraArguments(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	extraArguments "vars" {
+	  commands = ["plan", "apply"]
+	  arguments = ["-var-file=common.tfvars"]
+	}
+
+	extraArguments "no_lock" {
+	  commands = ["plan"]
+	  arguments = ["-lock=false"]
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "child"),
+	`
+	include = {
+	  path = "${find_in_parent_folders()}"
+	}
+
+	extraArguments "vars" {
+	  commands = ["plan"]
+	  arguments = ["-var-file=child.tfvars"]
+	}
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []ExtraArguments{
+		{Name: "no_lock", Commands: []string{"plan"}, Arguments: []string{"-lock=false"}},
+		{Name: "vars", Commands: []string{"plan"}, Arguments: []string{"-var-file=child.tfvars"}},
+	}, terragruntConfig.ExtraArguments)
+}
+
+func TestParseTerragruntConfigHooks(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	beforeHook "secrets" {
+	  commands = ["plan", "apply"]
+	  execute = ["./fetch-secrets.sh", "${get_env("TF_ENV", "dev")}"]
+	}
+
+	afterHook "notify" {
+	  commands = ["apply"]
+	  execute = ["./notify.sh"]
+	  runOnError = true
+	  workingDir = "../scripts"
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Hook{{Name: "secrets", Commands: []string{"plan", "apply"}, Execute: []string{"./fetch-secrets.sh", "dev"}}}, terragruntConfig.BeforeHooks)
+	assert.Equal(t, []Hook{{Name: "notify", Commands: []string{"apply"}, Execute: []string{"./notify.sh"}, RunOnError: true, WorkingDir: "../scripts"}}, terragruntConfig.AfterHooks)
+}
+
+func TestParseTerragruntConfigHookMissingExecute(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	afterHook "notify" {
+	  commands = ["apply"]
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, HookExecuteMissing("notify")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigIncludeMergesHooks(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	beforeHook "secrets" {
+	  commands = ["plan", "apply"]
+	  execute = ["./fetch-secrets.sh"]
+	}
+
+	afterHook "notify" {
+	  commands = ["apply"]
+	  execute = ["./notify.sh"]
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "child"),
+	`
+	include = {
+	  path = "${find_in_parent_folders()}"
+	}
+
+	afterHook "notify" {
+	  commands = ["apply", "destroy"]
+	  execute = ["./notify.sh", "child"]
+	}
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Hook{{Name: "secrets", Commands: []string{"plan", "apply"}, Execute: []string{"./fetch-secrets.sh"}}}, terragruntConfig.BeforeHooks)
+	assert.Equal(t, []Hook{{Name: "notify", Commands: []string{"apply", "destroy"}, Execute: []string{"./notify.sh", "child"}}}, terragruntConfig.AfterHooks)
+}
+
+func TestParseTerragruntConfigTerraformSource(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	terraform = {
+	  source = "git::https://github.com/foo/modules.git//app?ref=${get_env("MODULES_VERSION", "v1.2")}"
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.Terraform)
+	assert.Equal(t, "git::https://github.com/foo/modules.git//app?ref=v1.2", terragruntConfig.Terraform.Source)
+}
+
+func TestParseTerragruntConfigIncludeTerraformSource(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	terraform = {
+	  source = "git::https://github.com/foo/modules.git//${path_relative_to_include()}?ref=v1.2"
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "app"),
+	`
+	include = {
+	  path = "${find_in_parent_folders()}"
+	}
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.Terraform)
+	assert.Equal(t, "git::https://github.com/foo/modules.git//app?ref=v1.2", terragruntConfig.Terraform.Source)
+}
+
+func TestParseTerragruntConfigIncludeTerraformBinary(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir, `terraformBinary = "terraform-0.9"`)
+
+	inheritingConfigPath := writeConfigFile(t, filepath.Join(rootDir, "app"), `include = { path = "../.terragrunt" }`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(inheritingConfigPath))
+	assert.Nil(t, err)
+	assert.Equal(t, "terraform-0.9", terragruntConfig.TerraformBinary)
+
+	overridingConfigPath := writeConfigFile(t, filepath.Join(rootDir, "legacy"),
+	`
+	include = { path = "../.terragrunt" }
+	terraformBinary = "./bin/terraform"
+	`)
+
+	terragruntConfig, err = ReadTerragruntConfig(options.NewTerragruntOptionsForTest(overridingConfigPath))
+	assert.Nil(t, err)
+	assert.Equal(t, "./bin/terraform", terragruntConfig.TerraformBinary)
+}
+
+func TestParseTerragruntConfigModuleDependencies(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dependencies = {
+	  paths = ["../vpc", "../mysql"]
+	}
+
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+
+	dependency "redis" {
+	  path = "../redis"
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, terragruntConfig.ModuleDependencies)
+	assert.Equal(t, []string{"../vpc", "../mysql"}, terragruntConfig.ModuleDependencies.Paths)
+	assert.Equal(t, []string{"../vpc", "../mysql", "../redis"}, terragruntConfig.DependencyPaths())
+}
+
+func TestMakePathsAbsolute(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig := &TerragruntConfig{
+		StateBackup: &remote.StateBackup{BackupDir: ".terragrunt-backups"},
+		ModuleDependencies: &ModuleDependencies{Paths: []string{"../vpc"}},
+		Dependencies: []remote.Dependency{{Name: "vpc", Path: "../vpc"}, {Name: "db", Path: "/live/db"}},
+		ExtraArguments: []ExtraArguments{{Name: "vars", Commands: []string{"plan"}, OptionalVarFiles: []string{"dev.tfvars"}}},
+		BeforeHooks: []Hook{{Name: "secrets", Commands: []string{"plan"}, Execute: []string{"./secrets.sh"}}},
+		AfterHooks: []Hook{{Name: "notify", Commands: []string{"apply"}, Execute: []string{"./notify.sh"}, WorkingDir: "../scripts"}},
+	}
+
+	assert.Nil(t, terragruntConfig.MakePathsAbsolute("/live/app"))
+
+	assert.Equal(t, "/live/app/.terragrunt-backups", terragruntConfig.StateBackup.BackupDir)
+	assert.Equal(t, []string{"/live/vpc"}, terragruntConfig.ModuleDependencies.Paths)
+	assert.Equal(t, []remote.Dependency{{Name: "vpc", Path: "/live/vpc"}, {Name: "db", Path: "/live/db"}}, terragruntConfig.Dependencies)
+	assert.Equal(t, []string{"/live/app/dev.tfvars"}, terragruntConfig.ExtraArguments[0].OptionalVarFiles)
+	assert.Equal(t, "", terragruntConfig.BeforeHooks[0].WorkingDir)
+	assert.Equal(t, "/live/scripts", terragruntConfig.AfterHooks[0].WorkingDir)
+}
+
+func TestParseTerragruntConfigJson(t *testing.T) {
+	t.Parallel()
+
+	hclConfig :=
+	`
+	dynamoDbLock = {
+	  stateFileId = "expected-state-file-id"
+	  maxLockRetries = 10
+	}
+
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    bucket = "my-bucket"
+	  }
+	}
+
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+
+	dependency "mysql" {
+	  path = "../mysql"
+	}
+
+	afterHook "notify" {
+	  commands = ["apply"]
+	  execute = ["./notify.sh"]
+	  runOnError = true
+	}
+	`
+
+	jsonConfig :=
+	`{
+	  "dynamoDbLock": {
+	    "stateFileId": "expected-state-file-id",
+	    "maxLockRetries": 10
+	  },
+	  "remoteState": {
+	    "backend": "s3",
+	    "backendConfigs": {
+	      "bucket": "my-bucket"
+	    }
+	  },
+	  "dependency": {
+	    "vpc": {"path": "../vpc"},
+	    "mysql": {"path": "../mysql"}
+	  },
+	  "afterHook": {
+	    "notify": {
+	      "commands": ["apply"],
+	      "execute": ["./notify.sh"],
+	      "runOnError": true
+	    }
+	  }
+	}`
+
+	expected, err := parseTerragruntConfig(hclConfig, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	actual, err := parseTerragruntConfig(jsonConfig, options.NewTerragruntOptionsForTest(TERRAGRUNT_JSON_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	autodetected, err := parseTerragruntConfig(jsonConfig, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, autodetected)
+}
+
+func TestParseTerragruntConfigJsonFlattenedBlocks(t *testing.T) {
+	t.Parallel()
+
+	// The HCL JSON parser flattens objects whose values are all objects, such as this remoteState
+	config :=
+	`{
+	  "remoteState": {
+	    "backendConfigs": {
+	      "bucket": "my-bucket"
+	    }
+	  }
+	}`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_JSON_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, remote.RemoteBackendMissing), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigJsonIncludesHcl(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    key = "${path_relative_to_include()}/terraform.tfstate"
+	  }
+	}
+	`)
+
+	childConfigPath := writeJsonConfigFile(t, filepath.Join(rootDir, "stage", "mysql"),
+	`{
+	  "include": {
+	    "path": "${find_in_parent_folders()}"
+	  },
+	  "dependency": {
+	    "vpc": {"path": "../../vpc"}
+	  }
+	}`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+	assert.Equal(t, "stage/mysql/terraform.tfstate", terragruntConfig.RemoteState.BackendConfigs["key"])
+	assert.Equal(t, []remote.Dependency{{Name: "vpc", Path: "../../vpc"}}, terragruntConfig.Dependencies)
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	assert.Equal(t, filepath.Join(rootDir, TERRAGRUNT_CONFIG_FILE), DefaultConfigPath(rootDir))
+
+	jsonConfigPath := writeJsonConfigFile(t, rootDir, "{}")
+	assert.Equal(t, jsonConfigPath, DefaultConfigPath(rootDir))
+
+	hclConfigPath := writeConfigFile(t, rootDir, "")
+	assert.Equal(t, hclConfigPath, DefaultConfigPath(rootDir))
+}
+
+func TestMergeHooks(t *testing.T) {
+	t.Parallel()
+
+	hooks := []Hook{{Name: "a", Commands: []string{"apply"}}, {Name: "b", Commands: []string{"plan"}}}
+	overrides := []Hook{{Name: "b", Commands: []string{"destroy"}}, {Name: "c", Commands: []string{"apply"}}}
+
+	expected := []Hook{{Name: "a", Commands: []string{"apply"}}, {Name: "b", Commands: []string{"destroy"}}, {Name: "c", Commands: []string{"apply"}}}
+	assert.Equal(t, expected, mergeHooks(hooks, overrides))
+	assert.Equal(t, []Hook{}, mergeHooks(nil, nil))
+}
+
+func createTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "terragrunt-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeConfigFile(t *testing.T, dir string, contents string) string {
+	return writeFile(t, dir, TERRAGRUNT_CONFIG_FILE, contents)
+}
+
+func writeJsonConfigFile(t *testing.T, dir string, contents string) string {
+	return writeFile(t, dir, TERRAGRUNT_JSON_CONFIG_FILE, contents)
+}
+
+func writeFile(t *testing.T, dir string, fileName string, contents string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, fileName)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,477 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	hclParser "github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+	jsonParser "github.com/hashicorp/hcl/json/parser"
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Parse the given Terragrunt config, which was read from the config file at the given path, without decoding it. The
+// config is parsed as JSON if the file name ends in .json or the config looks like JSON (i.e. starts with {), and as
+// HCL otherwise. A syntax error is returned as an InvalidConfig error, with the position of the problem.
+func parseConfigSyntax(config string, configPath string) (*ast.File, error) {
+	validator := &configValidator{configPath: configPath}
+
+	if strings.HasSuffix(configPath, JSON_CONFIG_FILE_EXTENSION) || strings.HasPrefix(strings.TrimSpace(config), "{") {
+		// The HCL JSON parser silently skips over some syntax errors, such as a missing comma or value, so check the
+		// syntax with the standard JSON parser first
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+			syntaxErr, isSyntaxErr := err.(*json.SyntaxError)
+			if !isSyntaxErr {
+				return nil, errors.WithStackTrace(err)
+			}
+			validator.addProblem(positionOfOffset(config, syntaxErr.Offset), "", "%s", syntaxErr)
+			return nil, errors.WithStackTrace(validator.problems)
+		}
+
+		file, err := jsonParser.Parse([]byte(config))
+		return file, errors.WithStackTrace(err)
+	}
+
+	file, err := hcl.Parse(config)
+	if posErr, isPosErr := err.(*hclParser.PosError); isPosErr {
+		validator.addProblem(posErr.Pos, "", "%s", posErr.Err)
+		return nil, errors.WithStackTrace(validator.problems)
+	}
+
+	return file, errors.WithStackTrace(err)
+}
+
+// Return the line and column of the character before the given byte offset in the given string. The JSON parser
+// reports the offset just after the character where it found a problem.
+func positionOfOffset(str string, offset int64) token.Pos {
+	pos := token.Pos{Line: 1, Column: 1}
+
+	for i, char := range str {
+		if int64(i) >= offset - 1 {
+			break
+		}
+		if char == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+
+	return pos
+}
+
+// Check the structure of the given parsed Terragrunt config, which was read from the config file at the given path,
+// against the fields of TerragruntConfig. hcl.Decode silently ignores keys that don't match a field (and matches keys
+// without regard to case), so a misspelled setting such as backendConfig would otherwise be ignored without a word.
+// Every key must be the name of a field with the first letter in lower case (e.g. dynamoDbLock for DynamoDbLock), or
+// the name in its hcl tag, and every value must have the type of the field. Return an InvalidConfig error that lists
+// all the problems found, rather than just the first one.
+func validateConfigStructure(file *ast.File, configPath string) error {
+	list, isList := file.Node.(*ast.ObjectList)
+	if !isList {
+		return nil
+	}
+
+	validator := &configValidator{configPath: configPath}
+	validator.validateObject(list, reflect.TypeOf(TerragruntConfig{}), "")
+
+	if len(validator.problems) > 0 {
+		return errors.WithStackTrace(validator.problems)
+	}
+
+	return nil
+}
+
+// Collects the problems found while checking the structure of a Terragrunt config file
+type configValidator struct {
+	configPath string
+	problems   InvalidConfig
+}
+
+// Record a problem at the given position in the config file with the setting at the given path (e.g.
+// remoteState.backend), if any
+func (validator *configValidator) addProblem(pos token.Pos, path string, message string, args ... interface{}) {
+	validator.problems = append(validator.problems, ConfigProblem{
+		ConfigPath: validator.configPath,
+		Line: pos.Line,
+		Column: pos.Column,
+		Path: path,
+		Message: fmt.Sprintf(message, args...),
+	})
+}
+
+// Check each of the items in the given object against the fields of the given struct type. The given name is the
+// full name of the object (e.g. remoteState), or an empty string for the top level of the config.
+func (validator *configValidator) validateObject(list *ast.ObjectList, structType reflect.Type, name string) {
+	fields := configFields(structType)
+	seen := map[string]bool{}
+
+	for _, item := range nestFlattenedItems(list, fields).Items {
+		key := itemKey(item.Keys[0])
+
+		field, found := fields[key]
+		if !found {
+			validator.addProblem(item.Pos(), joinName(name, key), "%s%s", unknownKeyMessage(key, name), suggestionMessage(key, fields))
+			continue
+		}
+
+		fieldName := joinName(name, key)
+
+		if isLabeledBlocks(field.Type) {
+			validator.validateLabeledBlock(item, field.Type.Elem(), fieldName)
+			continue
+		}
+
+		if seen[key] {
+			validator.addProblem(item.Pos(), fieldName, "%s is set more than once", fieldName)
+			continue
+		}
+		seen[key] = true
+
+		if len(item.Keys) > 1 {
+			validator.addProblem(item.Pos(), fieldName, "%s does not take a name", fieldName)
+			continue
+		}
+
+		validator.validateValue(item.Val, field.Type, fieldName)
+	}
+}
+
+// The HCL JSON parser flattens objects whose values are all objects into items with more than one key, so
+// {"dependency": {"vpc": {"path": "../vpc"}}} is parsed as if it were dependency "vpc" { path = "../vpc" }, which is
+// what's expected for a block with a name, but {"remoteState": {"backendConfigs": {...}}} is parsed as remoteState
+// "backendConfigs" { ... }. Return a copy of the given object in which the items for blocks have been nested again:
+// the keys after the field name (or after the block name, for blocks with a name) become keys in the block. Items for
+// the same block are merged into one.
+func nestFlattenedItems(list *ast.ObjectList, fields map[string]configField) *ast.ObjectList {
+	out := &ast.ObjectList{}
+	blocks := map[string]*ast.ObjectType{}
+
+	for _, item := range list.Items {
+		field, found := fields[itemKey(item.Keys[0])]
+		if !found {
+			out.Add(item)
+			continue
+		}
+
+		blockKeys := 0
+		if isLabeledBlocks(field.Type) {
+			blockKeys = 2
+		} else if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			blockKeys = 1
+		}
+
+		if blockKeys == 0 || len(item.Keys) <= blockKeys {
+			out.Add(item)
+			continue
+		}
+
+		blockId := ""
+		for _, key := range item.Keys[:blockKeys] {
+			blockId = blockId + "." + itemKey(key)
+		}
+
+		block, alreadyAdded := blocks[blockId]
+		if !alreadyAdded {
+			block = &ast.ObjectType{List: &ast.ObjectList{}}
+			blocks[blockId] = block
+			out.Add(&ast.ObjectItem{Keys: item.Keys[:blockKeys], Val: block})
+		}
+
+		block.List.Add(&ast.ObjectItem{Keys: item.Keys[blockKeys:], Assign: item.Assign, Val: item.Val})
+	}
+
+	return out
+}
+
+// Check a block that has a name, such as dependency "vpc" { ... }, against the given struct type
+func (validator *configValidator) validateLabeledBlock(item *ast.ObjectItem, structType reflect.Type, name string) {
+	if len(item.Keys) != 2 {
+		validator.addProblem(item.Pos(), name, "Each %s block must have exactly one name, e.g. %s \"example\" { ... }", name, name)
+		return
+	}
+
+	object, isObject := item.Val.(*ast.ObjectType)
+	if !isObject {
+		validator.addProblem(item.Val.Pos(), name, "The value of %s must be a block", name)
+		return
+	}
+
+	validator.validateObject(object.List, structType, joinName(name, itemKey(item.Keys[1])))
+}
+
+// Check that the given value has the given type
+func (validator *configValidator) validateValue(value ast.Node, valueType reflect.Type, name string) {
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		object, isObject := value.(*ast.ObjectType)
+		if !isObject {
+			validator.addProblem(value.Pos(), name, "The value of %s must be a block", name)
+			return
+		}
+		validator.validateObject(object.List, valueType, name)
+
+	case reflect.Slice:
+		list, isList := value.(*ast.ListType)
+		if !isList || !allOfType(list.List, valueType.Elem().Kind()) {
+			validator.addProblem(value.Pos(), name, "The value of %s must be a list of %ss", name, typeDescription(valueType.Elem().Kind()))
+		}
+
+	case reflect.Map:
+		object, isObject := value.(*ast.ObjectType)
+		if !isObject {
+			validator.addProblem(value.Pos(), name, "The value of %s must be a map of %ss", name, typeDescription(valueType.Elem().Kind()))
+			return
+		}
+		for _, item := range object.List.Items {
+			if len(item.Keys) != 1 || !isOfType(item.Val, valueType.Elem().Kind()) {
+				itemName := joinName(name, itemKey(item.Keys[0]))
+				validator.addProblem(item.Pos(), itemName, "The value of %s must be a %s", itemName, typeDescription(valueType.Elem().Kind()))
+			}
+		}
+
+	default:
+		if !isOfType(value, valueType.Kind()) {
+			validator.addProblem(value.Pos(), name, "The value of %s must be a %s", name, typeDescription(valueType.Kind()))
+		}
+	}
+}
+
+// A field of a config struct that can be set in a config file
+type configField struct {
+	// The name used for the field in the config file
+	Name  string
+	Index int
+	Type  reflect.Type
+}
+
+// Return the fields of the given struct type that can be set in a config file, in the order they are declared. The
+// field with the hcl ",key" tag holds the name of a block, so it can't be set directly.
+func configFieldList(structType reflect.Type) []configField {
+	fields := []configField{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("hcl")
+
+		if field.PkgPath != "" || strings.HasSuffix(tag, ",key") || tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = lowerFirst(field.Name)
+		}
+
+		fields = append(fields, configField{Name: name, Index: i, Type: field.Type})
+	}
+
+	return fields
+}
+
+// Return the fields of the given struct type that can be set in a config file, keyed by the name used for them in the
+// config file
+func configFields(structType reflect.Type) map[string]configField {
+	fields := map[string]configField{}
+	for _, field := range configFieldList(structType) {
+		fields[field.Name] = field
+	}
+	return fields
+}
+
+// Return the index of the field of the given struct type that holds the name of the block, or -1 if there is none
+func blockNameField(structType reflect.Type) int {
+	for i := 0; i < structType.NumField(); i++ {
+		if strings.HasSuffix(structType.Field(i).Tag.Get("hcl"), ",key") {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return true if the given field type is a list of blocks that each have a name, such as dependency "vpc" { ... }
+func isLabeledBlocks(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct && blockNameField(fieldType.Elem()) >= 0
+}
+
+// Return true if all the given values are of the given kind
+func allOfType(values []ast.Node, kind reflect.Kind) bool {
+	for _, value := range values {
+		if !isOfType(value, kind) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Return true if the given value is a literal that hcl.Decode can decode into a field of the given kind
+func isOfType(value ast.Node, kind reflect.Kind) bool {
+	literal, isLiteral := value.(*ast.LiteralType)
+	if !isLiteral {
+		return false
+	}
+
+	switch kind {
+	case reflect.String:
+		return literal.Token.Type == token.STRING || literal.Token.Type == token.HEREDOC || literal.Token.Type == token.NUMBER
+	case reflect.Int:
+		if literal.Token.Type == token.STRING {
+			_, err := strconv.ParseInt(literal.Token.Value().(string), 0, 0)
+			return err == nil
+		}
+		return literal.Token.Type == token.NUMBER
+	case reflect.Bool:
+		return literal.Token.Type == token.BOOL
+	default:
+		return false
+	}
+}
+
+// Return a description of the given kind of value for use in error messages
+func typeDescription(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String: return "string"
+	case reflect.Int: return "number"
+	case reflect.Bool: return "boolean"
+	default: return kind.String()
+	}
+}
+
+// Return the text of the given key, without quotes
+func itemKey(key *ast.ObjectKey) string {
+	if value, isString := key.Token.Value().(string); isString {
+		return value
+	}
+	return key.Token.Text
+}
+
+// Return the full name of the given key in the object with the given name, e.g. remoteState.backend
+func joinName(name string, key string) string {
+	if name == "" {
+		return key
+	}
+	return name + "." + key
+}
+
+// Return the given string with its first letter in lower case
+func lowerFirst(str string) string {
+	if str == "" {
+		return str
+	}
+	return string(unicode.ToLower(rune(str[0]))) + str[1:]
+}
+
+// Return the error message for the given unknown key in the object with the given name
+func unknownKeyMessage(key string, name string) string {
+	if name == "" {
+		return fmt.Sprintf("Unknown key %s", key)
+	}
+	return fmt.Sprintf("Unknown key %s in %s", key, name)
+}
+
+// If one of the given fields has a name similar to the given key, return a message suggesting it, or an empty string
+// otherwise. Names that differ only in case are always similar; otherwise, up to a third of the letters may differ.
+func suggestionMessage(key string, fields map[string]configField) string {
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bestName := ""
+	bestDistance := len(key) / 3 + 1
+
+	for _, name := range names {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance {
+			bestName = name
+			bestDistance = distance
+		}
+	}
+
+	if bestName == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(". Did you mean %s?", bestName)
+}
+
+// Return the Levenshtein distance between the given strings: the number of single character insertions, deletions,
+// and substitutions it takes to turn one into the other
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b) + 1)
+	current := make([]int, len(b) + 1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i - 1] == b[j - 1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j] + 1, current[j - 1] + 1, previous[j - 1] + cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// Return the smallest of the given numbers
+func minInt(first int, others ... int) int {
+	smallest := first
+	for _, other := range others {
+		if other < smallest {
+			smallest = other
+		}
+	}
+	return smallest
+}
+
+// A single problem found in the structure of a Terragrunt config file
+type ConfigProblem struct {
+	ConfigPath string
+	// The position of the problem in the config file. The HCL JSON parser doesn't record positions, so for JSON
+	// configs, these are 0, except for syntax errors.
+	Line       int
+	Column     int
+	// The path of the setting with the problem, with the keys and block names separated by dots, e.g.
+	// dependency.vpc.path. The path is the same for HCL and JSON configs. It's empty for syntax errors.
+	Path       string
+	Message    string
+}
+
+func (problem ConfigProblem) String() string {
+	if problem.Line == 0 {
+		return fmt.Sprintf("%s: %s", problem.ConfigPath, problem.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", problem.ConfigPath, problem.Line, problem.Column, problem.Message)
+}
+
+// All the problems found in the structure of a Terragrunt config file
+type InvalidConfig []ConfigProblem
+
+func (problems InvalidConfig) Error() string {
+	lines := []string{"The Terragrunt config has the following problems:"}
+	for _, problem := range problems {
+		lines = append(lines, "  " + problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
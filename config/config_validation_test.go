@@ -0,0 +1,251 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+func TestValidateConfigStructureValidConfig(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	dynamoDbLock = {
+	  stateFileId = "expected-state-file-id"
+	  maxLockRetries = 10
+	}
+
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    encrypt = "true"
+	    bucket = "my-bucket"
+	  }
+	}
+
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+
+	beforeHook "fetch_secrets" {
+	  commands = ["plan", "apply"]
+	  execute = ["./fetch-secrets.sh"]
+	  runOnError = true
+	}
+	`
+
+	file, err := parseConfigSyntax(config, TERRAGRUNT_CONFIG_FILE)
+	assert.Nil(t, err)
+	assert.Nil(t, validateConfigStructure(file, TERRAGRUNT_CONFIG_FILE))
+}
+
+func TestValidateConfigStructureUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`dynamoDBLock = {
+  stateFileId = "expected-state-file-id"
+}
+
+remoteState = {
+  backend = "s3"
+  backendConfig = {
+    bucket = "my-bucket"
+  }
+}
+
+somethingElse = "foo"
+`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_CONFIG_FILE)
+	assert.Equal(t, []string{
+		".terragrunt:1:1: Unknown key dynamoDBLock. Did you mean dynamoDbLock?",
+		".terragrunt:7:3: Unknown key backendConfig in remoteState. Did you mean backendConfigs?",
+		".terragrunt:12:1: Unknown key somethingElse",
+	}, problems)
+}
+
+func TestValidateConfigStructureWrongTypes(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`dynamoDbLock = {
+  stateFileId = ["expected-state-file-id"]
+  maxLockRetries = "lots"
+}
+
+extraArguments "common_vars" {
+  commands = "plan"
+  envVars = {
+    TF_LOG = ["INFO"]
+  }
+}
+
+afterHook "notify" {
+  commands = ["apply"]
+  execute = ["./notify.sh"]
+  runOnError = "yes"
+}
+
+terraform = "git::git@github.com:foo/modules.git"
+`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_CONFIG_FILE)
+	assert.Equal(t, []string{
+		".terragrunt:2:17: The value of dynamoDbLock.stateFileId must be a string",
+		".terragrunt:3:20: The value of dynamoDbLock.maxLockRetries must be a number",
+		".terragrunt:7:14: The value of extraArguments.common_vars.commands must be a list of strings",
+		".terragrunt:9:5: The value of extraArguments.common_vars.envVars.TF_LOG must be a string",
+		".terragrunt:16:16: The value of afterHook.notify.runOnError must be a boolean",
+		".terragrunt:19:13: The value of terraform must be a block",
+	}, problems)
+}
+
+func TestValidateConfigStructureBlockNames(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`dependency {
+  path = "../vpc"
+}
+
+remoteState "s3" {
+  backend = "s3"
+}
+
+remoteState = {
+  backend = "s3"
+}
+`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_CONFIG_FILE)
+	assert.Equal(t, []string{
+		".terragrunt:1:1: Each dependency block must have exactly one name, e.g. dependency \"example\" { ... }",
+		".terragrunt:5:13: Unknown key s3 in remoteState",
+		".terragrunt:9:1: remoteState is set more than once",
+	}, problems)
+}
+
+func TestValidateConfigStructureJson(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`{
+  "dynamoDBLock": {
+    "stateFileId": "expected-state-file-id"
+  },
+  "remoteState": {
+    "backendConfig": {
+      "bucket": "my-bucket"
+    }
+  },
+  "dependency": {
+    "vpc": {"path": ["../vpc"]}
+  },
+  "extraArguments": {
+    "common_vars": {"envVars": {"TF_LOG": "INFO"}}
+  }
+}`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_JSON_CONFIG_FILE)
+	assert.Equal(t, []string{
+		".terragrunt.json: Unknown key dynamoDBLock. Did you mean dynamoDbLock?",
+		".terragrunt.json: Unknown key backendConfig in remoteState. Did you mean backendConfigs?",
+		".terragrunt.json: The value of dependency.vpc.path must be a string",
+	}, problems)
+
+	err := validateConfigStructure(parseJsonForTest(t, config), TERRAGRUNT_JSON_CONFIG_FILE)
+	paths := []string{}
+	for _, problem := range errors.Unwrap(err).(InvalidConfig) {
+		paths = append(paths, problem.Path)
+	}
+	assert.Equal(t, []string{"dynamoDBLock", "remoteState.backendConfig", "dependency.vpc.path"}, paths)
+}
+
+func TestValidateConfigStructureJsonSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`{
+  "remoteState": {
+    "backend" "s3"
+  }
+}`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_JSON_CONFIG_FILE)
+	assert.Equal(t, []string{".terragrunt.json:3:15: invalid character '\"' after object key"}, problems)
+
+	// JSON is autodetected in .terragrunt files
+	problems = validateConfigProblems(t, config, TERRAGRUNT_CONFIG_FILE)
+	assert.Equal(t, []string{".terragrunt:3:15: invalid character '\"' after object key"}, problems)
+}
+
+func TestParseTerragruntConfigRejectsUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	remoteState = {
+	  backend = "s3"
+	  backendConfig = {
+	    bucket = "my-bucket"
+	  }
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.NotNil(t, err)
+	_, isInvalidConfig := errors.Unwrap(err).(InvalidConfig)
+	assert.True(t, isInvalidConfig, "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"backendconfig", "backendconfigs", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, editDistance(testCase.a, testCase.b), "For %s and %s", testCase.a, testCase.b)
+	}
+}
+
+func parseJsonForTest(t *testing.T, config string) *ast.File {
+	file, err := parseConfigSyntax(config, TERRAGRUNT_JSON_CONFIG_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// Parse the given config, read from a file at the given path, validate its structure, and return the problems found,
+// as strings
+func validateConfigProblems(t *testing.T, config string, configPath string) []string {
+	file, err := parseConfigSyntax(config, configPath)
+	if err == nil {
+		err = validateConfigStructure(file, configPath)
+	}
+
+	problems, isInvalidConfig := errors.Unwrap(err).(InvalidConfig)
+	if !isInvalidConfig {
+		t.Fatalf("Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	}
+
+	out := []string{}
+	for _, problem := range problems {
+		out = append(out, problem.String())
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/gruntwork-io/terragrunt/dynamodb"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches an encrypted value, such as ENC[kms,AQICAHh...], which names the provider that can decrypt it and holds the
+// base64-encoded ciphertext
+var ENCRYPTED_VALUE_REGEX = regexp.MustCompile(`^ENC\[([a-z]+),([A-Za-z0-9+/=\s]+)\]$`)
+
+// Matches the argument of decrypt: a quoted encrypted value
+var DECRYPT_ARGS_REGEX = regexp.MustCompile(`^\s*"([^"]*)"\s*$`)
+
+// The providers that can decrypt values
+const ENCRYPTION_PROVIDER_KMS = "kms"
+const ENCRYPTION_PROVIDER_AGE = "age"
+const ENCRYPTION_PROVIDER_PGP = "pgp"
+
+// age decrypts values using the identities in this file in the Terragrunt home dir, unless the
+// TERRAGRUNT_AGE_IDENTITY_FILE environment variable names a different file
+const DEFAULT_AGE_IDENTITY_FILE = "age/keys.txt"
+const AGE_IDENTITY_FILE_ENV_VAR = "TERRAGRUNT_AGE_IDENTITY_FILE"
+
+// KMS decrypts values in the region in this environment variable, or in the default region if it's not set
+const AWS_REGION_ENV_VAR = "AWS_REGION"
+
+// Decrypt the encrypted value in the given (unparsed) arguments, which are of the form "ENC[provider,ciphertext]". The
+// decrypted value is registered as a secret, so it never appears in the commands Terragrunt logs.
+func (context interpolationContext) decryptValue(args string) (string, error) {
+	argMatches := DECRYPT_ARGS_REGEX.FindStringSubmatch(args)
+	if argMatches == nil {
+		return "", errors.WithStackTrace(InvalidFunctionArgs{FunctionName: "decrypt", Args: args})
+	}
+
+	valueMatches := ENCRYPTED_VALUE_REGEX.FindStringSubmatch(argMatches[1])
+	if valueMatches == nil {
+		return "", errors.WithStackTrace(InvalidEncryptedValue(argMatches[1]))
+	}
+
+	provider := valueMatches[1]
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(valueMatches[2]), ""))
+	if err != nil {
+		return "", errors.WithStackTrace(InvalidEncryptedValue(argMatches[1]))
+	}
+
+	plaintext, err := context.decryptCiphertext(provider, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	// Tools such as echo add a newline at the end of the value they encrypt, which is never part of the secret
+	value := strings.TrimSuffix(string(plaintext), "\n")
+	context.registerSecret(value)
+
+	return value, nil
+}
+
+// Decrypt the given ciphertext with the given provider, using the environment variables, working directory, and logger
+// of the given options
+func decryptCiphertext(provider string, ciphertext []byte, terragruntOptions *options.TerragruntOptions) ([]byte, error) {
+	switch provider {
+	case ENCRYPTION_PROVIDER_KMS:
+		return decryptWithKms(ciphertext, terragruntOptions)
+	case ENCRYPTION_PROVIDER_AGE:
+		return decryptWithAge(ciphertext, terragruntOptions)
+	case ENCRYPTION_PROVIDER_PGP:
+		return decryptWithPgp(ciphertext, terragruntOptions)
+	default:
+		return nil, errors.WithStackTrace(UnknownEncryptionProvider(provider))
+	}
+}
+
+// Decrypt the given ciphertext with AWS KMS, using the current AWS credentials. KMS finds the key from the ciphertext.
+func decryptWithKms(ciphertext []byte, terragruntOptions *options.TerragruntOptions) ([]byte, error) {
+	region := terragruntOptions.Getenv(AWS_REGION_ENV_VAR)
+	if region == "" {
+		region = dynamodb.DEFAULT_AWS_REGION
+	}
+
+	config := defaults.Get().Config.WithRegion(region)
+	if _, err := config.Credentials.Get(); err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error finding AWS credentials to decrypt a value with KMS (did you set the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables?)")
+	}
+
+	output, err := kms.New(session.New(), config).Decrypt(&kms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error decrypting a value with KMS")
+	}
+
+	return output.Plaintext, nil
+}
+
+// Decrypt the given ciphertext with the age command, using the identities in the age identity file
+func decryptWithAge(ciphertext []byte, terragruntOptions *options.TerragruntOptions) ([]byte, error) {
+	identityFile := terragruntOptions.Getenv(AGE_IDENTITY_FILE_ENV_VAR)
+	if identityFile == "" {
+		terragruntHomeDir, err := util.GetTerragruntHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		identityFile = filepath.Join(terragruntHomeDir, DEFAULT_AGE_IDENTITY_FILE)
+	}
+
+	if !util.FileExists(identityFile) {
+		return nil, errors.WithStackTrace(AgeIdentityFileNotFound(identityFile))
+	}
+
+	plaintext, err := shell.RunShellCommandWithOptionsAndCaptureOutput(optionsWithInput(ciphertext, terragruntOptions), "age", "--decrypt", "--identity", identityFile)
+	if err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error decrypting a value with age")
+	}
+
+	return plaintext, nil
+}
+
+// Decrypt the given ciphertext with the gpg command, using the private keys in the local GnuPG keyring
+func decryptWithPgp(ciphertext []byte, terragruntOptions *options.TerragruntOptions) ([]byte, error) {
+	plaintext, err := shell.RunShellCommandWithOptionsAndCaptureOutput(optionsWithInput(ciphertext, terragruntOptions), "gpg", "--batch", "--quiet", "--decrypt")
+	if err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error decrypting a value with gpg")
+	}
+
+	return plaintext, nil
+}
+
+// Return a copy of the given options whose reader is the given input, so a command run with them reads it on stdin
+func optionsWithInput(input []byte, terragruntOptions *options.TerragruntOptions) *options.TerragruntOptions {
+	inputOptions := terragruntOptions.Clone()
+	inputOptions.Reader = bytes.NewReader(input)
+	return inputOptions
+}
+
+type InvalidEncryptedValue string
+
+func (value InvalidEncryptedValue) Error() string {
+	return fmt.Sprintf("Invalid encrypted value %s. Encrypted values must be of the form ENC[provider,ciphertext], where provider is %s, %s, or %s and ciphertext is base64-encoded.", string(value), ENCRYPTION_PROVIDER_KMS, ENCRYPTION_PROVIDER_AGE, ENCRYPTION_PROVIDER_PGP)
+}
+
+type UnknownEncryptionProvider string
+
+func (provider UnknownEncryptionProvider) Error() string {
+	return fmt.Sprintf("Unknown encryption provider %s. Supported providers are %s, %s, and %s.", string(provider), ENCRYPTION_PROVIDER_KMS, ENCRYPTION_PROVIDER_AGE, ENCRYPTION_PROVIDER_PGP)
+}
+
+type AgeIdentityFileNotFound string
+
+func (path AgeIdentityFileNotFound) Error() string {
+	return fmt.Sprintf("Cannot decrypt a value with age, as the age identity file %s does not exist. Create it, or set the %s environment variable to the path of your identity file.", string(path), AGE_IDENTITY_FILE_ENV_VAR)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+func TestResolveInterpolationsDecrypt(t *testing.T) {
+	t.Parallel()
+
+	context := mockInterpolationContext("my-app", map[string]string{})
+
+	testCases := []struct {
+		str      string
+		expected string
+	}{
+		{encryptedValueForTest("kms", "my-token"), "kms:my-token"},
+		{encryptedValueForTest("age", "my-token"), "age:my-token"},
+		{"token=" + encryptedValueForTest("pgp", "my-token"), "token=pgp:my-token"},
+		{`${decrypt( "ENC[kms,bXkt dG9rZW4=]" )}`, "kms:my-token"},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := context.resolveInterpolations(testCase.str)
+		assert.Nil(t, err, "For string %s: %v", testCase.str, err)
+		assert.Equal(t, testCase.expected, actual, "For string %s", testCase.str)
+	}
+}
+
+func TestResolveInterpolationsDecryptRegistersSecret(t *testing.T) {
+	t.Parallel()
+
+	secrets := []string{}
+	context := mockInterpolationContext("my-app", map[string]string{})
+	context.registerSecret = func(value string) { secrets = append(secrets, value) }
+
+	actual, err := context.resolveInterpolations("token=" + encryptedValueForTest("kms", "my-token"))
+	assert.Nil(t, err)
+	assert.Equal(t, "token=kms:my-token", actual)
+	assert.Equal(t, []string{"kms:my-token"}, secrets)
+}
+
+func TestResolveInterpolationsDecryptInvalidValues(t *testing.T) {
+	t.Parallel()
+
+	context := mockInterpolationContext("my-app", map[string]string{})
+
+	testCases := []struct {
+		str      string
+		expected error
+	}{
+		{`${decrypt()}`, InvalidFunctionArgs{FunctionName: "decrypt", Args: ""}},
+		{`${decrypt("ENC[kms,bXktdG9rZW4=]", "extra")}`, InvalidFunctionArgs{FunctionName: "decrypt", Args: `"ENC[kms,bXktdG9rZW4=]", "extra"`}},
+		{`${decrypt("my-token")}`, InvalidEncryptedValue("my-token")},
+		{`${decrypt("ENC[kms,not base64!]")}`, InvalidEncryptedValue("ENC[kms,not base64!]")},
+		{`${decrypt("ENC[kms,bXktdG9rZW4]")}`, InvalidEncryptedValue("ENC[kms,bXktdG9rZW4]")},
+	}
+
+	for _, testCase := range testCases {
+		_, err := context.resolveInterpolations(testCase.str)
+		assert.True(t, errors.IsError(err, testCase.expected), "For string %s: unexpected error of type %s: %s", testCase.str, reflect.TypeOf(err), err)
+	}
+}
+
+func TestDecryptCiphertextUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	_, err := decryptCiphertext("vault", []byte("my-token"), options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, UnknownEncryptionProvider("vault")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestDecryptCiphertextAgeIdentityFileNotFound(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Env[AGE_IDENTITY_FILE_ENV_VAR] = "/this/path/does/not/exist"
+
+	_, err := decryptCiphertext(ENCRYPTION_PROVIDER_AGE, []byte("my-token"), terragruntOptions)
+	assert.True(t, errors.IsError(err, AgeIdentityFileNotFound("/this/path/does/not/exist")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+// Return an interpolation that decrypts the given plaintext "encrypted" for the given provider. The mock interpolation
+// context's decryption just prefixes the ciphertext with the provider.
+func encryptedValueForTest(provider string, plaintext string) string {
+	return `${decrypt("ENC[` + provider + "," + base64.StdEncoding.EncodeToString([]byte(plaintext)) + `]")}`
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"github.com/gruntwork-io/terragrunt/dynamodb"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/remote"
+)
+
+// Settings that replace the lock and remote state settings of the rest of the config when Terragrunt runs in the
+// environment with the given name, e.g. environment "prod" { ... }, which is selected with the --terragrunt-env option.
+// Each block replaces the whole block of the same name; the blocks are not merged field by field.
+type Environment struct {
+	Name         string `hcl:",key"`
+	DynamoDbLock *dynamodb.DynamoDbLock
+	RemoteState  *remote.RemoteState
+}
+
+// Validate that no two of the given environments have the same name
+func ValidateEnvironments(environments []Environment) error {
+	names := map[string]bool{}
+
+	for _, environment := range environments {
+		if names[environment.Name] {
+			return errors.WithStackTrace(DuplicateEnvironmentName(environment.Name))
+		}
+		names[environment.Name] = true
+	}
+
+	return nil
+}
+
+// Replace the lock and remote state settings of this config with those of the environment with the given name, if it
+// sets them, and remove the environment blocks, which have no further use. If the name is empty, no environment is
+// selected and the settings are left as they are. Otherwise, the environment must be declared in this config.
+func (terragruntConfig *TerragruntConfig) selectEnvironment(name string) error {
+	if err := ValidateEnvironments(terragruntConfig.Environments); err != nil {
+		return err
+	}
+
+	environments := terragruntConfig.Environments
+	terragruntConfig.Environments = nil
+
+	if name == "" {
+		return nil
+	}
+
+	for _, environment := range environments {
+		if environment.Name != name {
+			continue
+		}
+
+		if environment.DynamoDbLock != nil {
+			terragruntConfig.DynamoDbLock = environment.DynamoDbLock
+		}
+		if environment.RemoteState != nil {
+			terragruntConfig.RemoteState = environment.RemoteState
+		}
+		return nil
+	}
+
+	names := []string{}
+	for _, environment := range environments {
+		names = append(names, environment.Name)
+	}
+	return errors.WithStackTrace(EnvironmentNotFound{Name: name, Declared: strings.Join(names, ", ")})
+}
+
+// The backendConfigs setting that holds the location of the state file for each backend that environments can
+// namespace, e.g. the key within the bucket for s3
+var ENVIRONMENT_NAMESPACED_BACKEND_CONFIGS = map[string]string{
+	"s3":     "key",
+	"consul": "path",
+	"gcs":    "prefix",
+}
+
+// Prefix the stateFileId of the lock and the location of the state file in the backendConfigs of the remote state
+// (e.g. the key for s3) with the name of the given environment, e.g. prod/my-app, and put state backups in a folder
+// named after the environment, so that two environments never share a lock, a state file, or backups, even if they
+// have the same settings. Return an error if the remote state uses a backend whose state location can't be namespaced.
+func (terragruntConfig *TerragruntConfig) namespaceForEnvironment(name string) error {
+	if terragruntConfig.DynamoDbLock != nil {
+		terragruntConfig.DynamoDbLock.StateFileId = environmentPrefix(name, terragruntConfig.DynamoDbLock.StateFileId)
+	}
+
+	if terragruntConfig.RemoteState != nil {
+		configName, isSupported := ENVIRONMENT_NAMESPACED_BACKEND_CONFIGS[terragruntConfig.RemoteState.Backend]
+		if !isSupported {
+			return errors.WithStackTrace(BackendNotSupportedForEnvironments{Environment: name, Backend: terragruntConfig.RemoteState.Backend})
+		}
+
+		if value, hasValue := terragruntConfig.RemoteState.BackendConfigs[configName]; hasValue {
+			terragruntConfig.RemoteState.BackendConfigs[configName] = environmentPrefix(name, value)
+		}
+	}
+
+	if terragruntConfig.StateBackup != nil {
+		terragruntConfig.StateBackup.BackupDir = filepath.Join(terragruntConfig.StateBackup.BackupDir, name)
+	}
+
+	return nil
+}
+
+// Return the given value prefixed with the name of the given environment
+func environmentPrefix(name string, value string) string {
+	return name + "/" + value
+}
+
+type DuplicateEnvironmentName string
+
+func (name DuplicateEnvironmentName) Error() string {
+	return fmt.Sprintf("There is more than one environment named %s", string(name))
+}
+
+type EnvironmentNotFound struct {
+	Name     string
+	Declared string
+}
+
+func (err EnvironmentNotFound) Error() string {
+	if err.Declared == "" {
+		return fmt.Sprintf("Environment %s was selected, but the Terragrunt config doesn't declare any environment blocks. Add an environment \"%s\" { ... } block.", err.Name, err.Name)
+	}
+	return fmt.Sprintf("Environment %s was selected, but the Terragrunt config only declares these environments: %s", err.Name, err.Declared)
+}
+
+type BackendNotSupportedForEnvironments struct {
+	Environment string
+	Backend     string
+}
+
+func (err BackendNotSupportedForEnvironments) Error() string {
+	backends := []string{}
+	for backend := range ENVIRONMENT_NAMESPACED_BACKEND_CONFIGS {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+
+	return fmt.Sprintf("Environment %s was selected, but the remote state uses the %s backend, whose state location Terragrunt can't prefix with the environment name, so environments could overwrite each other's state. Environments only support these backends: %s", err.Environment, err.Backend, strings.Join(backends, ", "))
+}
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+const ENVIRONMENTS_TEST_CONFIG = `
+dynamoDbLock = {
+  stateFileId = "my-app"
+}
+
+remoteState = {
+  backend = "s3"
+  backendConfigs = {
+    bucket = "dev-bucket"
+    key = "my-app/terraform.tfstate"
+  }
+}
+
+environment "dev" {
+}
+
+environment "prod" {
+  remoteState = {
+    backend = "s3"
+    backendConfigs = {
+      bucket = "prod-bucket"
+      key = "${get_env("APP_NAME", "my-app")}/terraform.tfstate"
+    }
+  }
+}
+`
+
+func TestParseTerragruntConfigNoEnvironment(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "my-app", terragruntConfig.DynamoDbLock.StateFileId)
+	assert.Equal(t, map[string]string{"bucket": "dev-bucket", "key": "my-app/terraform.tfstate"}, terragruntConfig.RemoteState.BackendConfigs)
+	assert.Nil(t, terragruntConfig.Environments)
+}
+
+func TestParseTerragruntConfigEnvironmentOverrides(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Environment = "prod"
+
+	terragruntConfig, err := parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG, terragruntOptions)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "prod/my-app", terragruntConfig.DynamoDbLock.StateFileId)
+	assert.Equal(t, map[string]string{"bucket": "prod-bucket", "key": "prod/my-app/terraform.tfstate"}, terragruntConfig.RemoteState.BackendConfigs)
+	assert.Nil(t, terragruntConfig.Environments)
+}
+
+func TestParseTerragruntConfigEmptyEnvironment(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Environment = "dev"
+
+	terragruntConfig, err := parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG, terragruntOptions)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "dev/my-app", terragruntConfig.DynamoDbLock.StateFileId)
+	assert.Equal(t, map[string]string{"bucket": "dev-bucket", "key": "dev/my-app/terraform.tfstate"}, terragruntConfig.RemoteState.BackendConfigs)
+}
+
+func TestParseTerragruntConfigEnvironmentNamespacesStateBackups(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Environment = "prod"
+
+	terragruntConfig, err := parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG + `
+stateBackup = {
+  backupDir = "backups"
+}
+`, terragruntOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("backups", "prod"), terragruntConfig.StateBackup.BackupDir)
+
+	terragruntConfig, err = parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG + `
+stateBackup = {
+}
+`, terragruntOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(".terragrunt-backups", "prod"), terragruntConfig.StateBackup.BackupDir)
+}
+
+func TestParseTerragruntConfigEnvironmentNamespacesEachBackend(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		backend  string
+		config   string
+		expected map[string]string
+	}{
+		{"s3", `key = "my-app/terraform.tfstate"`, map[string]string{"key": "prod/my-app/terraform.tfstate"}},
+		{"consul", `path = "my-app/terraform.tfstate"`, map[string]string{"path": "prod/my-app/terraform.tfstate"}},
+		{"gcs", `prefix = "my-app"`, map[string]string{"prefix": "prod/my-app"}},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+		terragruntOptions.Environment = "prod"
+
+		config := fmt.Sprintf("remoteState = {\n  backend = \"%s\"\n  backendConfigs = {\n    %s\n  }\n}\n\nenvironment \"prod\" {\n}\n", testCase.backend, testCase.config)
+
+		terragruntConfig, err := parseTerragruntConfig(config, terragruntOptions)
+		if assert.Nil(t, err, "For backend %s", testCase.backend) {
+			assert.Equal(t, testCase.expected, terragruntConfig.RemoteState.BackendConfigs, "For backend %s", testCase.backend)
+		}
+	}
+}
+
+func TestParseTerragruntConfigEnvironmentUnsupportedBackend(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Environment = "prod"
+
+	config :=
+	`
+	remoteState = {
+	  backend = "artifactory"
+	  backendConfigs = {
+	    repo = "my-repo"
+	  }
+	}
+
+	environment "prod" {
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, terragruntOptions)
+	expected := BackendNotSupportedForEnvironments{Environment: "prod", Backend: "artifactory"}
+	assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	terragruntOptions.Environment = ""
+	_, err = parseTerragruntConfig(config, terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+}
+
+func TestParseTerragruntConfigEnvironmentNotFound(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE)
+	terragruntOptions.Environment = "stage"
+
+	_, err := parseTerragruntConfig(ENVIRONMENTS_TEST_CONFIG, terragruntOptions)
+	expected := EnvironmentNotFound{Name: "stage", Declared: "dev, prod"}
+	assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	_, err = parseTerragruntConfig(`dynamoDbLock = { stateFileId = "my-app" }`, terragruntOptions)
+	expected = EnvironmentNotFound{Name: "stage", Declared: ""}
+	assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigDuplicateEnvironmentName(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	environment "prod" {
+	}
+
+	environment "prod" {
+	}
+	`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.True(t, errors.IsError(err, DuplicateEnvironmentName("prod")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigIncludeMergesEnvironments(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	dynamoDbLock = {
+	  stateFileId = "${path_relative_to_include()}"
+	}
+
+	environment "stage" {
+	  dynamoDbLock = {
+	    stateFileId = "stage-${path_relative_to_include()}"
+	  }
+	}
+
+	environment "prod" {
+	}
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "app"),
+	`
+	include = {
+	  path = "${find_in_parent_folders()}"
+	}
+
+	environment "stage" {
+	}
+	`)
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(childConfigPath)
+
+	terragruntOptions.Environment = "stage"
+	terragruntConfig, err := ReadTerragruntConfig(terragruntOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, "stage/app", terragruntConfig.DynamoDbLock.StateFileId)
+
+	terragruntOptions.Environment = "prod"
+	terragruntConfig, err = ReadTerragruntConfig(terragruntOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, "prod/app", terragruntConfig.DynamoDbLock.StateFileId)
+}
+
+func TestParseTerragruntConfigEnvironmentJson(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`{
+	  "dynamoDbLock": {"stateFileId": "my-app"},
+	  "environment": {
+	    "prod": {"dynamoDbLock": {"stateFileId": "my-prod-app", "maxLockRetries": 10}}
+	  }
+	}`
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(TERRAGRUNT_JSON_CONFIG_FILE)
+	terragruntOptions.Environment = "prod"
+
+	terragruntConfig, err := parseTerragruntConfig(config, terragruntOptions)
+	assert.Nil(t, err)
+	assert.Equal(t, "prod/my-prod-app", terragruntConfig.DynamoDbLock.StateFileId)
+	assert.Equal(t, 10, terragruntConfig.DynamoDbLock.MaxLockRetries)
+}
+
+func TestValidateConfigStructureEnvironments(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`environment "prod" {
+  remoteStates = {
+    backend = "s3"
+  }
+}
+`
+
+	problems := validateConfigProblems(t, config, TERRAGRUNT_CONFIG_FILE)
+	assert.Equal(t, []string{".terragrunt:2:3: Unknown key remoteStates in environment.prod. Did you mean remoteState?"}, problems)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Extra arguments, environment variables, and var files to pass to Terraform whenever it runs one of the given
+// commands. For example, this can be used to always pass -var-file=common.tfvars to plan and apply.
+type ExtraArguments struct {
+	Name             string            `hcl:",key"`
+	Commands         []string
+	Arguments        []string
+	EnvVars          map[string]string
+	OptionalVarFiles []string
+}
+
+// Fill in any default configuration for the extra arguments
+func (extraArgs *ExtraArguments) FillDefaults() {
+	// Nothing to do
+}
+
+// Validate that the extra arguments are configured correctly
+func (extraArgs *ExtraArguments) Validate() error {
+	if len(extraArgs.Commands) == 0 {
+		return errors.WithStackTrace(ExtraArgumentsCommandsMissing(extraArgs.Name))
+	}
+
+	return nil
+}
+
+// Return true if these extra arguments should be passed to the given Terraform command
+func (extraArgs ExtraArguments) AppliesTo(command string) bool {
+	for _, extraArgsCommand := range extraArgs.Commands {
+		if extraArgsCommand == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate that each of the given extra arguments blocks is configured correctly and that no two have the same name
+func ValidateExtraArguments(extraArgsList []ExtraArguments) error {
+	names := map[string]bool{}
+
+	for _, extraArgs := range extraArgsList {
+		if err := extraArgs.Validate(); err != nil {
+			return err
+		}
+
+		if names[extraArgs.Name] {
+			return errors.WithStackTrace(DuplicateExtraArgumentsName(extraArgs.Name))
+		}
+		names[extraArgs.Name] = true
+	}
+
+	return nil
+}
+
+// Return the arguments and environment variables to pass to the given Terraform command, based on all the extra
+// arguments blocks that apply to it, in the order they are declared. Each optional var file is passed with -var-file
+// only if it exists; relative var file paths are relative to the given working directory, as that is where Terraform
+// runs. If more than one block sets the same environment variable, the last one wins.
+func ExtraArgumentsForCommand(extraArgsList []ExtraArguments, command string, workingDir string) ([]string, map[string]string) {
+	args := []string{}
+	envVars := map[string]string{}
+
+	for _, extraArgs := range extraArgsList {
+		if !extraArgs.AppliesTo(command) {
+			continue
+		}
+
+		args = append(args, extraArgs.Arguments...)
+
+		for _, varFile := range extraArgs.OptionalVarFiles {
+			if util.FileExists(joinWithWorkingDir(workingDir, varFile)) {
+				args = append(args, fmt.Sprintf("-var-file=%s", varFile))
+			}
+		}
+
+		for name, value := range extraArgs.EnvVars {
+			envVars[name] = value
+		}
+	}
+
+	return args, envVars
+}
+
+// Return the given path joined with the given working directory, unless the path is absolute
+func joinWithWorkingDir(workingDir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(workingDir, path)
+}
+
+type ExtraArgumentsCommandsMissing string
+
+func (name ExtraArgumentsCommandsMissing) Error() string {
+	return fmt.Sprintf("The commands parameter must be specified for extraArguments %s", string(name))
+}
+
+type DuplicateExtraArgumentsName string
+
+func (name DuplicateExtraArgumentsName) Error() string {
+	return fmt.Sprintf("There is more than one extraArguments block named %s", string(name))
+}
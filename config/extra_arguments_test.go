@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+func TestValidateExtraArguments(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		extraArgsList []ExtraArguments
+		expectedErr   error
+	}{
+		{[]ExtraArguments{}, nil},
+		{[]ExtraArguments{{Name: "vars", Commands: []string{"plan", "apply"}, Arguments: []string{"-var-file=common.tfvars"}}}, nil},
+		{[]ExtraArguments{{Name: "vars", Arguments: []string{"-var-file=common.tfvars"}}}, ExtraArgumentsCommandsMissing("vars")},
+		{[]ExtraArguments{{Name: "vars", Commands: []string{"plan"}}, {Name: "vars", Commands: []string{"apply"}}}, DuplicateExtraArgumentsName("vars")},
+	}
+
+	for _, testCase := range testCases {
+		err := ValidateExtraArguments(testCase.extraArgsList)
+		if testCase.expectedErr == nil {
+			assert.Nil(t, err, "For extra arguments %v", testCase.extraArgsList)
+		} else {
+			assert.True(t, errors.IsError(err, testCase.expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		}
+	}
+}
+
+func TestExtraArgumentsForCommand(t *testing.T) {
+	t.Parallel()
+
+	workingDir, err := ioutil.TempDir("", "terragrunt-extra-args-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workingDir)
+
+	if err := ioutil.WriteFile(filepath.Join(workingDir, "exists.tfvars"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	extraArgsList := []ExtraArguments{
+		{
+			Name: "vars",
+			Commands: []string{"plan", "apply"},
+			Arguments: []string{"-var-file=common.tfvars"},
+			OptionalVarFiles: []string{"exists.tfvars", "does-not-exist.tfvars"},
+			EnvVars: map[string]string{"TF_LOG": "INFO", "FOO": "bar"},
+		},
+		{
+			Name: "no_lock",
+			Commands: []string{"plan"},
+			Arguments: []string{"-lock=false"},
+			EnvVars: map[string]string{"TF_LOG": "DEBUG"},
+		},
+	}
+
+	testCases := []struct {
+		command         string
+		expectedArgs    []string
+		expectedEnvVars map[string]string
+	}{
+		{"plan", []string{"-var-file=common.tfvars", "-var-file=exists.tfvars", "-lock=false"}, map[string]string{"TF_LOG": "DEBUG", "FOO": "bar"}},
+		{"apply", []string{"-var-file=common.tfvars", "-var-file=exists.tfvars"}, map[string]string{"TF_LOG": "INFO", "FOO": "bar"}},
+		{"output", []string{}, map[string]string{}},
+	}
+
+	for _, testCase := range testCases {
+		args, envVars := ExtraArgumentsForCommand(extraArgsList, testCase.command, workingDir)
+		assert.Equal(t, testCase.expectedArgs, args, "For command %s", testCase.command)
+		assert.Equal(t, testCase.expectedEnvVars, envVars, "For command %s", testCase.command)
+	}
+}
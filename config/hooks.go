@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// A command to run before or after Terraform runs one of the given Terraform commands. For example, a hook can fetch
+// secrets before plan or post a message to a chat room after apply.
+type Hook struct {
+	Name       string `hcl:",key"`
+	// The Terraform commands, such as plan or apply, around which to run this hook
+	Commands   []string
+	// The command to run and its arguments, e.g. ["./fetch-secrets.sh", "stage"]
+	Execute    []string
+	// If true, run this hook even if Terraform or an earlier hook failed
+	RunOnError bool
+	// The folder to run the command in. A relative path is relative to the working directory, which is the default.
+	WorkingDir string
+}
+
+// Fill in any default configuration for the hook
+func (hook *Hook) FillDefaults() {
+	// Nothing to do
+}
+
+// Validate that the hook is configured correctly
+func (hook *Hook) Validate() error {
+	if len(hook.Commands) == 0 {
+		return errors.WithStackTrace(HookCommandsMissing(hook.Name))
+	}
+
+	if len(hook.Execute) == 0 {
+		return errors.WithStackTrace(HookExecuteMissing(hook.Name))
+	}
+
+	return nil
+}
+
+// Return true if this hook should run around the given Terraform command
+func (hook Hook) AppliesTo(command string) bool {
+	for _, hookCommand := range hook.Commands {
+		if hookCommand == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate that each of the given hooks is configured correctly and that no two have the same name
+func ValidateHooks(hooks []Hook) error {
+	names := map[string]bool{}
+
+	for _, hook := range hooks {
+		if err := hook.Validate(); err != nil {
+			return err
+		}
+
+		if names[hook.Name] {
+			return errors.WithStackTrace(DuplicateHookName(hook.Name))
+		}
+		names[hook.Name] = true
+	}
+
+	return nil
+}
+
+// Return the hooks that should run around the given Terraform command, in the order they are declared
+func HooksForCommand(hooks []Hook, command string) []Hook {
+	out := []Hook{}
+
+	for _, hook := range hooks {
+		if hook.AppliesTo(command) {
+			out = append(out, hook)
+		}
+	}
+
+	return out
+}
+
+type HookCommandsMissing string
+
+func (name HookCommandsMissing) Error() string {
+	return fmt.Sprintf("The commands parameter must be specified for hook %s", string(name))
+}
+
+type HookExecuteMissing string
+
+func (name HookExecuteMissing) Error() string {
+	return fmt.Sprintf("The execute parameter must be specified for hook %s", string(name))
+}
+
+type DuplicateHookName string
+
+func (name DuplicateHookName) Error() string {
+	return fmt.Sprintf("There is more than one hook named %s", string(name))
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"reflect"
+)
+
+func TestValidateHooks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		hooks       []Hook
+		expectedErr error
+	}{
+		{[]Hook{}, nil},
+		{[]Hook{{Name: "secrets", Commands: []string{"plan"}, Execute: []string{"./fetch-secrets.sh"}}}, nil},
+		{[]Hook{{Name: "secrets", Execute: []string{"./fetch-secrets.sh"}}}, HookCommandsMissing("secrets")},
+		{[]Hook{{Name: "secrets", Commands: []string{"plan"}}}, HookExecuteMissing("secrets")},
+		{[]Hook{{Name: "secrets", Commands: []string{"plan"}, Execute: []string{"a"}}, {Name: "secrets", Commands: []string{"apply"}, Execute: []string{"b"}}}, DuplicateHookName("secrets")},
+	}
+
+	for _, testCase := range testCases {
+		err := ValidateHooks(testCase.hooks)
+		if testCase.expectedErr == nil {
+			assert.Nil(t, err, "For hooks %v", testCase.hooks)
+		} else {
+			assert.True(t, errors.IsError(err, testCase.expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		}
+	}
+}
+
+func TestHooksForCommand(t *testing.T) {
+	t.Parallel()
+
+	secrets := Hook{Name: "secrets", Commands: []string{"plan", "apply"}, Execute: []string{"./fetch-secrets.sh"}}
+	notify := Hook{Name: "notify", Commands: []string{"apply"}, Execute: []string{"./notify.sh"}}
+	hooks := []Hook{secrets, notify}
+
+	assert.Equal(t, []Hook{secrets}, HooksForCommand(hooks, "plan"))
+	assert.Equal(t, []Hook{secrets, notify}, HooksForCommand(hooks, "apply"))
+	assert.Equal(t, []Hook{}, HooksForCommand(hooks, "output"))
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Settings for redacting secrets from everything Terragrunt logs. The values of arguments, variables, and backend
+// configs whose names contain password, secret, token, and the like are always redacted.
+type Redaction struct {
+	// The names of other arguments, variables, and backend configs, e.g. db_pass, whose values must be redacted
+	Keys     []string
+
+	// Regular expressions, e.g. AKIA[0-9A-Z]{16}, whose matches must be redacted wherever they appear
+	Patterns []string
+}
+
+// Fill in any default configuration for redaction
+func (redaction *Redaction) FillDefaults() {
+	// Nothing to do
+}
+
+// Validate that the redaction settings are correct
+func (redaction *Redaction) Validate() error {
+	for _, pattern := range redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.WithStackTrace(InvalidRedactionPattern{Pattern: pattern, Err: err.Error()})
+		}
+	}
+
+	return nil
+}
+
+// Register the sensitive keys and patterns from the redaction block and the values of the sensitive remote state
+// backend configs in this config, so they're redacted from everything Terragrunt logs from now on. The config must
+// have been validated.
+func (terragruntConfig *TerragruntConfig) RegisterSecrets() {
+	if terragruntConfig.Redaction != nil {
+		for _, key := range terragruntConfig.Redaction.Keys {
+			util.RegisterSensitiveKey(key)
+		}
+		for _, pattern := range terragruntConfig.Redaction.Patterns {
+			util.RegisterSensitivePattern(regexp.MustCompile(pattern))
+		}
+	}
+
+	if terragruntConfig.RemoteState != nil {
+		terragruntConfig.RemoteState.RegisterSecrets()
+	}
+}
+
+type InvalidRedactionPattern struct {
+	Pattern string
+	Err     string
+}
+
+func (err InvalidRedactionPattern) Error() string {
+	return fmt.Sprintf("Invalid regular expression %s in redaction.patterns: %s", err.Pattern, err.Err)
+}
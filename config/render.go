@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Map keys that match this regex can be written in HCL without quotes
+var HCL_IDENTIFIER_REGEX = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Render the given config, which is typically the result of ReadTerragruntConfig with includes merged and
+// interpolations resolved, in the HCL format of a .terragrunt file. Settings that are not set are left out, and map
+// values whose keys look sensitive, such as backendConfigs.secret_key, are redacted.
+func RenderConfigAsHcl(terragruntConfig *TerragruntConfig) string {
+	out := &bytes.Buffer{}
+	renderHclBlockBody(out, reflect.ValueOf(*terragruntConfig), "")
+	return out.String()
+}
+
+// Render the given config as JSON, using the same keys as the HCL format. Blocks with a name, such as dependency
+// blocks, become objects keyed by name. Settings that are not set are left out, and map values whose keys look
+// sensitive are redacted.
+func RenderConfigAsJson(terragruntConfig *TerragruntConfig) (string, error) {
+	out := &bytes.Buffer{}
+
+	// Don't escape <, >, and &, so values such as <redacted> come out as they are
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(jsonValue(reflect.ValueOf(*terragruntConfig))); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// Write the settings of the given struct to the given buffer in HCL format, with the given indent. Top-level blocks
+// are separated by blank lines.
+func renderHclBlockBody(out *bytes.Buffer, value reflect.Value, indent string) {
+	for _, field := range configFieldList(value.Type()) {
+		fieldValue := value.Field(field.Index)
+		if isUnset(fieldValue) {
+			continue
+		}
+
+		if isLabeledBlocks(field.Type) {
+			for i := 0; i < fieldValue.Len(); i++ {
+				block := fieldValue.Index(i)
+				fmt.Fprintf(out, "%s%s %s {\n", indent, field.Name, strconv.Quote(block.Field(blockNameField(block.Type())).String()))
+				renderHclBlockBody(out, block, indent + "  ")
+				fmt.Fprintf(out, "%s}\n", indent)
+				if indent == "" {
+					fmt.Fprintln(out)
+				}
+			}
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.Elem().Kind() == reflect.Struct {
+			fmt.Fprintf(out, "%s%s {\n", indent, field.Name)
+			renderHclBlockBody(out, fieldValue.Elem(), indent + "  ")
+			fmt.Fprintf(out, "%s}\n", indent)
+			if indent == "" {
+				fmt.Fprintln(out)
+			}
+			continue
+		}
+
+		fmt.Fprintf(out, "%s%s = %s\n", indent, field.Name, hclValue(fieldValue, indent))
+	}
+}
+
+// Return the given string, number, boolean, list of strings, or map of strings in HCL format
+func hclValue(value reflect.Value, indent string) string {
+	switch value.Kind() {
+	case reflect.String:
+		return strconv.Quote(value.String())
+
+	case reflect.Slice:
+		items := []string{}
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, hclValue(value.Index(i), indent))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+
+	case reflect.Map:
+		lines := []string{"{"}
+		for _, key := range sortedMapKeys(value) {
+			lines = append(lines, fmt.Sprintf("%s  %s = %s", indent, hclKey(key), hclValue(mapValue(value, key), indent + "  ")))
+		}
+		lines = append(lines, indent + "}")
+		return strings.Join(lines, "\n")
+
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
+
+// Return the given map key in HCL format, quoting it if necessary
+func hclKey(key string) string {
+	if HCL_IDENTIFIER_REGEX.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// Convert the given value into maps, slices, and simple values that encoding/json renders with the config file keys
+func jsonValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr:
+		return jsonValue(value.Elem())
+
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		for _, field := range configFieldList(value.Type()) {
+			fieldValue := value.Field(field.Index)
+			if isUnset(fieldValue) {
+				continue
+			}
+
+			if isLabeledBlocks(field.Type) {
+				blocks := map[string]interface{}{}
+				for i := 0; i < fieldValue.Len(); i++ {
+					block := fieldValue.Index(i)
+					blocks[block.Field(blockNameField(block.Type())).String()] = jsonValue(block)
+				}
+				out[field.Name] = blocks
+			} else {
+				out[field.Name] = jsonValue(fieldValue)
+			}
+		}
+		return out
+
+	case reflect.Map:
+		out := map[string]interface{}{}
+		for _, key := range sortedMapKeys(value) {
+			out[key] = jsonValue(mapValue(value, key))
+		}
+		return out
+
+	default:
+		return value.Interface()
+	}
+}
+
+// Return the value for the given key in the given map of strings, or the redacted placeholder if the key looks
+// sensitive
+func mapValue(value reflect.Value, key string) reflect.Value {
+	if util.IsSensitiveKey(key) {
+		return reflect.ValueOf(util.REDACTED)
+	}
+	return value.MapIndex(reflect.ValueOf(key))
+}
+
+// Return true if the given setting is not set: a nil block, an empty string, or an empty list or map
+func isUnset(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr: return value.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map: return value.Len() == 0
+	default: return false
+	}
+}
+
+// Return the keys of the given map of strings, sorted
+func sortedMapKeys(value reflect.Value) []string {
+	keys := []string{}
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
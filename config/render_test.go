@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+const RENDER_TEST_CONFIG = `
+dynamoDbLock = {
+  stateFileId = "${path_relative_to_include()}"
+}
+
+remoteState = {
+  backend = "s3"
+  backendConfigs = {
+    bucket = "my-bucket"
+    "key.with.dots" = "${get_env("TEST_KEY", "default-key")}"
+  }
+}
+
+dependency "vpc" {
+  path = "../vpc"
+}
+
+extraArguments "common_vars" {
+  commands = ["plan", "apply"]
+  envVars = {
+    TF_LOG = "INFO"
+  }
+}
+`
+
+func TestRenderConfigAsHcl(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseTerragruntConfig(RENDER_TEST_CONFIG, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	expected :=
+`dynamoDbLock {
+  stateFileId = "."
+  awsRegion = "us-east-1"
+  tableName = "terragrunt_locks"
+  maxLockRetries = 360
+}
+
+remoteState {
+  backend = "s3"
+  backendConfigs = {
+    bucket = "my-bucket"
+    "key.with.dots" = "default-key"
+  }
+}
+
+dependency "vpc" {
+  path = "../vpc"
+}
+
+extraArguments "common_vars" {
+  commands = ["plan", "apply"]
+  envVars = {
+    TF_LOG = "INFO"
+  }
+}
+
+`
+
+	rendered := RenderConfigAsHcl(terragruntConfig)
+	assert.Equal(t, expected, rendered)
+
+	reparsedConfig, err := parseTerragruntConfig(rendered, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, terragruntConfig, reparsedConfig)
+}
+
+func TestRenderConfigAsJson(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseTerragruntConfig(RENDER_TEST_CONFIG, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	rendered, err := RenderConfigAsJson(terragruntConfig)
+	assert.Nil(t, err)
+
+	var actual interface{}
+	assert.Nil(t, json.Unmarshal([]byte(rendered), &actual))
+
+	var expected interface{}
+	assert.Nil(t, json.Unmarshal([]byte(`{
+	  "dynamoDbLock": {"stateFileId": ".", "awsRegion": "us-east-1", "tableName": "terragrunt_locks", "maxLockRetries": 360},
+	  "remoteState": {"backend": "s3", "backendConfigs": {"bucket": "my-bucket", "key.with.dots": "default-key"}},
+	  "dependency": {"vpc": {"path": "../vpc"}},
+	  "extraArguments": {"common_vars": {"commands": ["plan", "apply"], "envVars": {"TF_LOG": "INFO"}}}
+	}`), &expected))
+
+	assert.Equal(t, expected, actual)
+
+	reparsedConfig, err := parseTerragruntConfig(rendered, options.NewTerragruntOptionsForTest(TERRAGRUNT_JSON_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, terragruntConfig, reparsedConfig)
+}
+
+func TestRenderConfigRedactsSensitiveKeys(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	remoteState = {
+	  backend = "s3"
+	  backendConfigs = {
+	    bucket = "my-bucket"
+	    secret_key = "abc/123=="
+	  }
+	}
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+
+	rendered := RenderConfigAsHcl(terragruntConfig)
+	assert.Contains(t, rendered, `secret_key = "<redacted>"`)
+	assert.NotContains(t, rendered, "abc/123==")
+
+	rendered, err = RenderConfigAsJson(terragruntConfig)
+	assert.Nil(t, err)
+	assert.Contains(t, rendered, `"secret_key": "<redacted>"`)
+	assert.Contains(t, rendered, `"bucket": "my-bucket"`)
+	assert.NotContains(t, rendered, "abc/123==")
+}
+
+func TestRenderEmptyConfig(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", RenderConfigAsHcl(&TerragruntConfig{}))
+
+	rendered, err := RenderConfigAsJson(&TerragruntConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, "{}", rendered)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// Validate that the version constraints in this config, if any, are valid constraints, such as ">= 0.9.0, < 0.10.0"
+func (terragruntConfig *TerragruntConfig) validateVersionConstraints() error {
+	constraints := []struct {
+		setting    string
+		constraint string
+	}{
+		{"terraformVersionConstraint", terragruntConfig.TerraformVersionConstraint},
+		{"terragruntVersionConstraint", terragruntConfig.TerragruntVersionConstraint},
+	}
+
+	for _, constraint := range constraints {
+		if constraint.constraint == "" {
+			continue
+		}
+		if _, err := version.NewConstraint(constraint.constraint); err != nil {
+			return errors.WithStackTrace(InvalidVersionConstraint{Setting: constraint.setting, Constraint: constraint.constraint})
+		}
+	}
+
+	return nil
+}
+
+// Return an error if the given version of Terraform does not satisfy the terraformVersionConstraint in this config.
+// If there is no constraint, any version is fine.
+func (terragruntConfig *TerragruntConfig) CheckTerraformVersion(terraformVersion *version.Version) error {
+	if terragruntConfig.TerraformVersionConstraint == "" {
+		return nil
+	}
+
+	constraint, err := version.NewConstraint(terragruntConfig.TerraformVersionConstraint)
+	if err != nil {
+		return errors.WithStackTrace(InvalidVersionConstraint{Setting: "terraformVersionConstraint", Constraint: terragruntConfig.TerraformVersionConstraint})
+	}
+
+	if !constraint.Check(terraformVersion) {
+		return errors.WithStackTrace(TerraformVersionNotSatisfied{Version: terraformVersion.String(), Constraint: terragruntConfig.TerraformVersionConstraint})
+	}
+
+	return nil
+}
+
+// Return an error if the given version of Terragrunt does not satisfy the terragruntVersionConstraint in this config.
+// If there is no constraint, any version is fine.
+func (terragruntConfig *TerragruntConfig) CheckTerragruntVersion(terragruntVersion string) error {
+	if terragruntConfig.TerragruntVersionConstraint == "" {
+		return nil
+	}
+
+	constraint, err := version.NewConstraint(terragruntConfig.TerragruntVersionConstraint)
+	if err != nil {
+		return errors.WithStackTrace(InvalidVersionConstraint{Setting: "terragruntVersionConstraint", Constraint: terragruntConfig.TerragruntVersionConstraint})
+	}
+
+	parsedVersion, err := version.NewVersion(terragruntVersion)
+	if err != nil {
+		return errors.WithStackTrace(InvalidTerragruntVersion(terragruntVersion))
+	}
+
+	if !constraint.Check(parsedVersion) {
+		return errors.WithStackTrace(TerragruntVersionNotSatisfied{Version: terragruntVersion, Constraint: terragruntConfig.TerragruntVersionConstraint})
+	}
+
+	return nil
+}
+
+type InvalidVersionConstraint struct {
+	Setting    string
+	Constraint string
+}
+
+func (err InvalidVersionConstraint) Error() string {
+	return fmt.Sprintf("Invalid %s %q. Use a constraint such as \">= 0.9.0, < 0.10.0\".", err.Setting, err.Constraint)
+}
+
+type TerraformVersionNotSatisfied struct {
+	Version    string
+	Constraint string
+}
+
+func (err TerraformVersionNotSatisfied) Error() string {
+	return fmt.Sprintf("The installed version of Terraform, %s, does not satisfy the terraformVersionConstraint %q in the Terragrunt config. Install a version of Terraform that does.", err.Version, err.Constraint)
+}
+
+type TerragruntVersionNotSatisfied struct {
+	Version    string
+	Constraint string
+}
+
+func (err TerragruntVersionNotSatisfied) Error() string {
+	return fmt.Sprintf("This version of Terragrunt, %s, does not satisfy the terragruntVersionConstraint %q in the Terragrunt config. Install a version of Terragrunt that does.", err.Version, err.Constraint)
+}
+
+type InvalidTerragruntVersion string
+
+func (terragruntVersion InvalidTerragruntVersion) Error() string {
+	return fmt.Sprintf("Cannot check the terragruntVersionConstraint in the Terragrunt config, as the version of Terragrunt, %q, is not a valid version", string(terragruntVersion))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+func TestParseTerragruntConfigVersionConstraints(t *testing.T) {
+	t.Parallel()
+
+	config :=
+	`
+	terraformVersionConstraint = ">= 0.9.0, < 0.10.0"
+	terragruntVersionConstraint = "~> 0.11"
+	`
+
+	terragruntConfig, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	assert.Nil(t, err)
+	assert.Equal(t, ">= 0.9.0, < 0.10.0", terragruntConfig.TerraformVersionConstraint)
+	assert.Equal(t, "~> 0.11", terragruntConfig.TerragruntVersionConstraint)
+}
+
+func TestParseTerragruntConfigInvalidVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	config := `terraformVersionConstraint = "at least 0.9"`
+
+	_, err := parseTerragruntConfig(config, options.NewTerragruntOptionsForTest(TERRAGRUNT_CONFIG_FILE))
+	expected := InvalidVersionConstraint{Setting: "terraformVersionConstraint", Constraint: "at least 0.9"}
+	assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestCheckTerraformVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		constraint string
+		version    string
+		satisfied  bool
+	}{
+		{"", "0.8.8", true},
+		{">= 0.9.0", "0.9.0", true},
+		{">= 0.9.0", "0.8.8", false},
+		{">= 0.9.0, < 0.10.0", "0.9.11", true},
+		{">= 0.9.0, < 0.10.0", "0.10.0", false},
+		{"~> 0.9.1", "0.9.5", true},
+		{"~> 0.9.1", "0.10.0", false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntConfig := &TerragruntConfig{TerraformVersionConstraint: testCase.constraint}
+		err := terragruntConfig.CheckTerraformVersion(version.Must(version.NewVersion(testCase.version)))
+
+		if testCase.satisfied {
+			assert.Nil(t, err, "For constraint %s and version %s", testCase.constraint, testCase.version)
+		} else {
+			expected := TerraformVersionNotSatisfied{Version: testCase.version, Constraint: testCase.constraint}
+			assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		}
+	}
+}
+
+func TestCheckTerragruntVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		constraint string
+		version    string
+		satisfied  bool
+	}{
+		{"", "v0.11.0", true},
+		{">= 0.11.0", "v0.11.0", true},
+		{">= 0.11.0", "0.11.1", true},
+		{">= 0.11.0", "v0.10.3", false},
+		{"< 1.0", "v1.0.0", false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntConfig := &TerragruntConfig{TerragruntVersionConstraint: testCase.constraint}
+		err := terragruntConfig.CheckTerragruntVersion(testCase.version)
+
+		if testCase.satisfied {
+			assert.Nil(t, err, "For constraint %s and version %s", testCase.constraint, testCase.version)
+		} else {
+			expected := TerragruntVersionNotSatisfied{Version: testCase.version, Constraint: testCase.constraint}
+			assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		}
+	}
+}
+
+func TestCheckTerragruntVersionInvalidVersion(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig := &TerragruntConfig{TerragruntVersionConstraint: ">= 0.11.0"}
+	err := terragruntConfig.CheckTerragruntVersion("dev-build")
+	assert.True(t, errors.IsError(err, InvalidTerragruntVersion("dev-build")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestParseTerragruntConfigIncludeVersionConstraints(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfigFile(t, rootDir,
+	`
+	terraformVersionConstraint = ">= 0.9.0"
+	terragruntVersionConstraint = ">= 0.11.0"
+	`)
+
+	childConfigPath := writeConfigFile(t, filepath.Join(rootDir, "child"),
+	`
+	include = {
+	  path = "../.terragrunt"
+	}
+
+	terraformVersionConstraint = "~> 0.9.5"
+	`)
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(childConfigPath))
+	assert.Nil(t, err)
+	assert.Equal(t, "~> 0.9.5", terragruntConfig.TerraformVersionConstraint)
+	assert.Equal(t, ">= 0.11.0", terragruntConfig.TerragruntVersionConstraint)
+}
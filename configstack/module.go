@@ -0,0 +1,184 @@
+package configstack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"github.com/gruntwork-io/terragrunt/config"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+// The file name patterns of Terraform files
+var TERRAFORM_FILE_PATTERNS = []string{"*.tf", "*.tf.json"}
+
+// A single folder of Terraform templates with its own Terragrunt config, and the other modules in the stack it depends
+// on
+type TerraformModule struct {
+	// The absolute path of the folder
+	Path              string
+	// The modules that must be applied before this one
+	Dependencies      []*TerraformModule
+	// The options to run Terragrunt with in this module
+	TerragruntOptions *options.TerragruntOptions
+}
+
+// Return a string representation of this module, for use in log messages and errors
+func (module *TerraformModule) String() string {
+	return module.Path
+}
+
+// Find all the folders under the working directory of the given options that contain a Terragrunt config file, read
+// their configs, and return them as modules with their dependencies resolved. The modules are sorted by path. Folders
+// whose config is included by the configs of other folders, and that have no Terraform files of their own, such as the
+// folder at the root of a repo that holds the shared settings, only hold settings, so they are skipped.
+func findModulesInSubfolders(terragruntOptions *options.TerragruntOptions) ([]*TerraformModule, error) {
+	rootDir, err := filepath.Abs(terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	folders, err := FindTerragruntConfigFolders(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	includedConfigPaths := map[string]bool{}
+	for _, folder := range folders {
+		includePath, err := config.ReadIncludePath(moduleOptionsForFolder(folder, terragruntOptions))
+		if err != nil {
+			return nil, err
+		}
+		if includePath != "" {
+			includedConfigPaths[includePath] = true
+		}
+	}
+
+	modules := map[string]*TerraformModule{}
+	dependencyPaths := map[string][]string{}
+
+	for _, modulePath := range folders {
+		moduleOptions := moduleOptionsForFolder(modulePath, terragruntOptions)
+
+		if includedConfigPaths[moduleOptions.TerragruntConfigPath] {
+			hasTerraformFiles, err := containsTerraformFiles(modulePath)
+			if err != nil {
+				return nil, err
+			}
+			if !hasTerraformFiles {
+				terragruntOptions.Logger.Printf("Skipping %s, as its Terragrunt config is included by other configs and it has no Terraform files", modulePath)
+				continue
+			}
+		}
+
+		terragruntConfig, err := config.ReadTerragruntConfig(moduleOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := terragruntConfig.MakePathsAbsolute(modulePath); err != nil {
+			return nil, err
+		}
+
+		modules[modulePath] = &TerraformModule{Path: modulePath, TerragruntOptions: moduleOptions}
+		dependencyPaths[modulePath] = terragruntConfig.DependencyPaths()
+	}
+
+	return resolveDependencies(modules, dependencyPaths)
+}
+
+// Return a copy of the given options for running Terragrunt in the given folder with the Terragrunt config in it
+func moduleOptionsForFolder(folder string, terragruntOptions *options.TerragruntOptions) *options.TerragruntOptions {
+	moduleOptions := terragruntOptions.Clone()
+	moduleOptions.WorkingDir = folder
+	moduleOptions.TerragruntConfigPath = config.DefaultConfigPath(folder)
+	return moduleOptions
+}
+
+// Return true if the given folder contains any Terraform files (*.tf or *.tf.json)
+func containsTerraformFiles(folder string) (bool, error) {
+	for _, pattern := range TERRAFORM_FILE_PATTERNS {
+		matches, err := filepath.Glob(filepath.Join(folder, pattern))
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Return the absolute paths of all the folders under the given folder, including the folder itself, that contain a
+// Terragrunt config file (.terragrunt or .terragrunt.json), sorted by path. Hidden folders, such as .terraform and
+// .git, are skipped.
+func FindTerragruntConfigFolders(rootDir string) ([]string, error) {
+	paths := []string{}
+	found := map[string]bool{}
+
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		if info.IsDir() {
+			if path != rootDir && info.Name()[0] == '.' {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if config.IsTerragruntConfigFile(info.Name()) && !found[filepath.Dir(path)] {
+			found[filepath.Dir(path)] = true
+			paths = append(paths, filepath.Dir(path))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Link each of the given modules, keyed by path, to the modules at the given dependency paths, and return the modules
+// sorted by path. Every dependency must be one of the given modules.
+func resolveDependencies(modules map[string]*TerraformModule, dependencyPaths map[string][]string) ([]*TerraformModule, error) {
+	paths := []string{}
+	for path := range modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	sortedModules := []*TerraformModule{}
+
+	for _, path := range paths {
+		module := modules[path]
+
+		for _, dependencyPath := range dependencyPaths[path] {
+			dependency, found := modules[filepath.Clean(dependencyPath)]
+			if !found {
+				return nil, errors.WithStackTrace(UnrecognizedDependency{ModulePath: path, DependencyPath: dependencyPath})
+			}
+			module.Dependencies = append(module.Dependencies, dependency)
+		}
+
+		sortedModules = append(sortedModules, module)
+	}
+
+	return sortedModules, nil
+}
+
+type UnrecognizedDependency struct {
+	ModulePath     string
+	DependencyPath string
+}
+
+func (err UnrecognizedDependency) Error() string {
+	return fmt.Sprintf("Module %s depends on %s, which does not contain a %s or %s file or is not in the folder the command was run in", err.ModulePath, err.DependencyPath, config.TERRAGRUNT_CONFIG_FILE, config.TERRAGRUNT_JSON_CONFIG_FILE)
+}
@@ -0,0 +1,126 @@
+package configstack
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func TestFindModulesInSubfolders(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfig(t, filepath.Join(rootDir, "vpc"), ``)
+	writeConfig(t, filepath.Join(rootDir, "mysql"), `dependencies = { paths = ["../vpc"] }`)
+	writeConfig(t, filepath.Join(rootDir, "app"),
+	`
+	dependencies = {
+	  paths = ["../vpc", "../mysql"]
+	}
+
+	dependency "vpc" {
+	  path = "../vpc"
+	}
+	`)
+	writeConfig(t, filepath.Join(rootDir, "app", ".terraform", "ignored"), ``)
+
+	modules, err := findModulesInSubfolders(testOptions(rootDir))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{filepath.Join(rootDir, "app"), filepath.Join(rootDir, "mysql"), filepath.Join(rootDir, "vpc")}, modulePaths(modules))
+	assert.Equal(t, []string{filepath.Join(rootDir, "vpc"), filepath.Join(rootDir, "mysql")}, modulePaths(modules[0].Dependencies))
+	assert.Equal(t, []string{filepath.Join(rootDir, "vpc")}, modulePaths(modules[1].Dependencies))
+	assert.Empty(t, modules[2].Dependencies)
+
+	assert.Equal(t, filepath.Join(rootDir, "app"), modules[0].TerragruntOptions.WorkingDir)
+	assert.Equal(t, filepath.Join(rootDir, "app", ".terragrunt"), modules[0].TerragruntOptions.TerragruntConfigPath)
+}
+
+func TestFindModulesInSubfoldersUnrecognizedDependency(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfig(t, filepath.Join(rootDir, "app"), `dependencies = { paths = ["../vpc"] }`)
+
+	_, err := findModulesInSubfolders(testOptions(rootDir))
+	assert.IsType(t, UnrecognizedDependency{}, errors.Unwrap(err))
+}
+
+func TestFindModulesInSubfoldersJsonConfigs(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfig(t, filepath.Join(rootDir, "vpc"), ``)
+	writeConfigFile(t, filepath.Join(rootDir, "mysql"), ".terragrunt.json", `{"dependencies": {"paths": ["../vpc"]}}`)
+
+	modules, err := findModulesInSubfolders(testOptions(rootDir))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{filepath.Join(rootDir, "mysql"), filepath.Join(rootDir, "vpc")}, modulePaths(modules))
+	assert.Equal(t, []string{filepath.Join(rootDir, "vpc")}, modulePaths(modules[0].Dependencies))
+	assert.Equal(t, filepath.Join(rootDir, "mysql", ".terragrunt.json"), modules[0].TerragruntOptions.TerragruntConfigPath)
+}
+
+func TestFindModulesInSubfoldersSkipsIncludedConfigsWithoutTerraformFiles(t *testing.T) {
+	t.Parallel()
+
+	rootDir := createTempDir(t)
+	defer os.RemoveAll(rootDir)
+
+	writeConfig(t, rootDir, `dynamoDbLock = { stateFileId = "${path_relative_to_include()}" }`)
+	writeConfig(t, filepath.Join(rootDir, "vpc"), `include = { path = "${find_in_parent_folders()}" }`)
+	writeConfig(t, filepath.Join(rootDir, "shared"), ``)
+	writeConfigFile(t, filepath.Join(rootDir, "shared"), "main.tf", ``)
+	writeConfig(t, filepath.Join(rootDir, "app"), `include = { path = "../shared/.terragrunt" }`)
+
+	modules, err := findModulesInSubfolders(testOptions(rootDir))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{filepath.Join(rootDir, "app"), filepath.Join(rootDir, "shared"), filepath.Join(rootDir, "vpc")}, modulePaths(modules))
+}
+
+func testOptions(rootDir string) *options.TerragruntOptions {
+	terragruntOptions := options.NewTerragruntOptionsForTest(filepath.Join(rootDir, ".terragrunt"))
+	terragruntOptions.WorkingDir = rootDir
+	terragruntOptions.Writer = ioutil.Discard
+	return terragruntOptions
+}
+
+func createTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "terragrunt-configstack-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeConfig(t *testing.T, dir string, contents string) {
+	writeConfigFile(t, dir, ".terragrunt", contents)
+}
+
+func writeConfigFile(t *testing.T, dir string, fileName string, contents string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, fileName), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func modulePaths(modules []*TerraformModule) []string {
+	paths := []string{}
+	for _, module := range modules {
+		paths = append(paths, module.Path)
+	}
+	return paths
+}
@@ -0,0 +1,65 @@
+package configstack
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"github.com/gruntwork-io/terragrunt/errors"
+)
+
+// A writer that adds a prefix to every line written to it and only writes whole lines to the underlying writer. When
+// modules run in parallel, each one gets its own prefix writers, all sharing the same mutex, so the lines they write
+// are labeled with the module and never mixed up with each other.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mutex  *sync.Mutex
+	buffer []byte
+}
+
+// Create a writer that writes lines to the given writer with the given prefix, using the given mutex to make sure only
+// one line is written at a time
+func newPrefixWriter(prefix string, out io.Writer, mutex *sync.Mutex) *prefixWriter {
+	return &prefixWriter{prefix: prefix, out: out, mutex: mutex}
+}
+
+// Buffer the given bytes and write every complete line in the buffer, with the prefix, to the underlying writer
+func (writer *prefixWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.buffer = append(writer.buffer, p...)
+
+	for {
+		i := bytes.IndexByte(writer.buffer, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if err := writer.writeLine(writer.buffer[:i + 1]); err != nil {
+			return 0, err
+		}
+		writer.buffer = writer.buffer[i + 1:]
+	}
+}
+
+// Write whatever is left in the buffer, which is an incomplete line, to the underlying writer with the prefix and a
+// newline
+func (writer *prefixWriter) Flush() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if len(writer.buffer) == 0 {
+		return nil
+	}
+
+	line := append(writer.buffer, '\n')
+	writer.buffer = nil
+	return writer.writeLine(line)
+}
+
+// Write the given line to the underlying writer with the prefix. Callers must hold the mutex.
+func (writer *prefixWriter) writeLine(line []byte) error {
+	_, err := writer.out.Write(append([]byte(writer.prefix), line...))
+	return errors.WithStackTrace(err)
+}
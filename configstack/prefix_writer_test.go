@@ -0,0 +1,60 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	writer := newPrefixWriter("[vpc] ", out, &sync.Mutex{})
+
+	fmt.Fprint(writer, "first line\nsecond ")
+	assert.Equal(t, "[vpc] first line\n", out.String())
+
+	fmt.Fprint(writer, "line\nincomplete")
+	assert.Equal(t, "[vpc] first line\n[vpc] second line\n", out.String())
+
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "[vpc] first line\n[vpc] second line\n[vpc] incomplete\n", out.String())
+
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "[vpc] first line\n[vpc] second line\n[vpc] incomplete\n", out.String())
+}
+
+func TestPrefixWritersDoNotMixLines(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	mutex := &sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+
+	for _, name := range []string{"vpc", "mysql", "app"} {
+		waitGroup.Add(1)
+		go func(name string) {
+			defer waitGroup.Done()
+			writer := newPrefixWriter(fmt.Sprintf("[%s] ", name), out, mutex)
+			for i := 0; i < 100; i++ {
+				fmt.Fprintf(writer, "%s line ", name)
+				fmt.Fprintf(writer, "%d\n", i)
+			}
+		}(name)
+	}
+	waitGroup.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, 300, len(lines))
+	for _, line := range lines {
+		var prefix, name string
+		var i int
+		_, err := fmt.Sscanf(line, "%s %s line %d", &prefix, &name, &i)
+		assert.Nil(t, err, "Malformed line: %s", line)
+		assert.Equal(t, fmt.Sprintf("[%s]", name), prefix, "Mixed up line: %s", line)
+	}
+}
@@ -0,0 +1,366 @@
+package configstack
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The function used to run Terragrunt in each module of a stack. The cli package passes in its RunTerragrunt function;
+// it can't be called directly, as the cli package depends on this one.
+type RunTerragruntFunc func(terragruntOptions *options.TerragruntOptions) error
+
+// The possible results of running a command in a module
+const (
+	MODULE_SUCCEEDED = "succeeded"
+	MODULE_FAILED    = "failed"
+	MODULE_SKIPPED   = "skipped"
+)
+
+// All the modules in a folder and its subfolders, in the order in which they must be applied: every module comes after
+// all of its dependencies
+type Stack struct {
+	Path    string
+	Modules []*TerraformModule
+}
+
+// The result of running a command in one module of a stack
+type ModuleResult struct {
+	Module *TerraformModule
+	Status string
+	Err    error
+}
+
+// Find all the modules in the working directory of the given options and its subfolders, and return them as a stack.
+// Return an error if a module depends on a folder that isn't a module in the stack, or if the dependencies have a
+// cycle.
+func FindStackInSubfolders(terragruntOptions *options.TerragruntOptions) (*Stack, error) {
+	rootDir, err := filepath.Abs(terragruntOptions.WorkingDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	modules, err := findModulesInSubfolders(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sortedModules, err := sortModules(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stack{Path: rootDir, Modules: sortedModules}, nil
+}
+
+// Sort the given modules so that every module comes after all of its dependencies. Modules that don't depend on each
+// other stay in the order they were given in. Return an error if the dependencies have a cycle.
+func sortModules(modules []*TerraformModule) ([]*TerraformModule, error) {
+	sorted := []*TerraformModule{}
+	done := map[*TerraformModule]bool{}
+	inProgress := map[*TerraformModule]bool{}
+
+	var visit func(module *TerraformModule, path []*TerraformModule) error
+	visit = func(module *TerraformModule, path []*TerraformModule) error {
+		if done[module] {
+			return nil
+		}
+
+		path = append(path, module)
+		if inProgress[module] {
+			return errors.WithStackTrace(DependencyCycle(cycleDescription(path)))
+		}
+		inProgress[module] = true
+
+		for _, dependency := range module.Dependencies {
+			if err := visit(dependency, path); err != nil {
+				return err
+			}
+		}
+
+		inProgress[module] = false
+		done[module] = true
+		sorted = append(sorted, module)
+		return nil
+	}
+
+	for _, module := range modules {
+		if err := visit(module, []*TerraformModule{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// Return a description of the cycle at the end of the given path of modules, such as "a -> b -> a"
+func cycleDescription(path []*TerraformModule) string {
+	last := path[len(path) - 1]
+
+	start := 0
+	for i, module := range path {
+		if module == last {
+			start = i
+			break
+		}
+	}
+
+	paths := []string{}
+	for _, module := range path[start:] {
+		paths = append(paths, module.Path)
+	}
+
+	return strings.Join(paths, " -> ")
+}
+
+// Run Terragrunt with the given Terraform args in each module of this stack using the given function, running up to
+// the given number of modules at the same time. Modules are run in dependency order or, if reverse is true (e.g. for
+// destroy), in reverse dependency order: a module only starts once all the modules that must come before it have
+// succeeded. If the command fails in a module, the modules that must come after it are skipped, but the others still
+// run. When all modules are done, write a summary of the results to the given writer and return an error if any
+// module failed.
+func (stack *Stack) Run(terraformArgs []string, reverse bool, parallelism int, runTerragrunt RunTerragruntFunc, writer io.Writer) error {
+	results := stack.runModules(terraformArgs, reverse, parallelism, runTerragrunt)
+	writeSummary(results, terraformArgs, writer)
+	return checkResults(results)
+}
+
+// Run Terragrunt with the given Terraform args in each module of this stack, running up to the given number of
+// modules at the same time, and return the result for each module, in run order. With a parallelism of 1, the modules
+// run one at a time in run order.
+func (stack *Stack) runModules(terraformArgs []string, reverse bool, parallelism int, runTerragrunt RunTerragruntFunc) []ModuleResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	pending := stack.ModulesInRunOrder(reverse)
+
+	prerequisites := dependenciesOf
+	if reverse {
+		prerequisites = dependentsOf(stack.Modules)
+	}
+
+	outputMutex := &sync.Mutex{}
+	done := make(chan ModuleResult)
+	running := 0
+
+	results := map[*TerraformModule]ModuleResult{}
+	statuses := map[*TerraformModule]string{}
+
+	for len(pending) > 0 || running > 0 {
+		stillPending := []*TerraformModule{}
+
+		for _, module := range pending {
+			if failed := failedPrerequisite(prerequisites(module), statuses); failed != nil {
+				module.TerragruntOptions.Logger.Printf("Skipping %s because %s did not succeed", module.Path, failed.Path)
+				result := ModuleResult{Module: module, Status: MODULE_SKIPPED, Err: PrerequisiteFailed(failed.Path)}
+				statuses[module] = result.Status
+				results[module] = result
+			} else if running < parallelism && prerequisitesSucceeded(prerequisites(module), statuses) {
+				moduleOptions := optionsForModule(module, stack.Path, terraformArgs, parallelism, outputMutex)
+				running++
+				go func(module *TerraformModule) {
+					done <- runModule(module, moduleOptions, runTerragrunt)
+				}(module)
+			} else {
+				stillPending = append(stillPending, module)
+			}
+		}
+
+		pending = stillPending
+
+		if running > 0 {
+			result := <-done
+			running--
+			statuses[result.Module] = result.Status
+			results[result.Module] = result
+		}
+	}
+
+	orderedResults := []ModuleResult{}
+	for _, module := range stack.ModulesInRunOrder(reverse) {
+		orderedResults = append(orderedResults, results[module])
+	}
+
+	return orderedResults
+}
+
+// Return the first of the given prerequisites that failed or was skipped, or nil if there is none
+func failedPrerequisite(prerequisites []*TerraformModule, statuses map[*TerraformModule]string) *TerraformModule {
+	for _, prerequisite := range prerequisites {
+		if statuses[prerequisite] == MODULE_FAILED || statuses[prerequisite] == MODULE_SKIPPED {
+			return prerequisite
+		}
+	}
+
+	return nil
+}
+
+// Return true if all the given prerequisites have succeeded
+func prerequisitesSucceeded(prerequisites []*TerraformModule, statuses map[*TerraformModule]string) bool {
+	for _, prerequisite := range prerequisites {
+		if statuses[prerequisite] != MODULE_SUCCEEDED {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Return the options to run Terragrunt with the given Terraform args in the given module. If more than one module can
+// run at the same time, every line the module writes is prefixed with its path relative to the given stack folder, so
+// the output of different modules doesn't get mixed up, and the module can't read input, as it's not clear which
+// module a prompt would belong to.
+func optionsForModule(module *TerraformModule, stackPath string, terraformArgs []string, parallelism int, outputMutex *sync.Mutex) *options.TerragruntOptions {
+	moduleOptions := module.TerragruntOptions.Clone()
+	moduleOptions.TerraformCliArgs = terraformArgs
+
+	if parallelism > 1 {
+		prefix := fmt.Sprintf("[%s] ", relativeModulePath(module, stackPath))
+		moduleOptions.Writer = newPrefixWriter(prefix, moduleOptions.Writer, outputMutex)
+		moduleOptions.ErrWriter = newPrefixWriter(prefix, moduleOptions.ErrWriter, outputMutex)
+		moduleOptions.Logger = log.New(util.NewRedactingWriter(moduleOptions.Writer), moduleOptions.Logger.Prefix(), moduleOptions.Logger.Flags())
+		moduleOptions.Reader = strings.NewReader("")
+		moduleOptions.NonInteractive = true
+	}
+
+	return moduleOptions
+}
+
+// Return the path of the given module relative to the given stack folder, or its full path if it's not in that folder
+func relativeModulePath(module *TerraformModule, stackPath string) string {
+	if stackPath == "" {
+		return module.Path
+	}
+
+	relativePath, err := filepath.Rel(stackPath, module.Path)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return module.Path
+	}
+
+	return relativePath
+}
+
+// Run Terragrunt in the given module with the given options and return the result. Any lines of output the module
+// left unfinished are written out when it's done.
+func runModule(module *TerraformModule, moduleOptions *options.TerragruntOptions, runTerragrunt RunTerragruntFunc) ModuleResult {
+	defer flushOutput(moduleOptions)
+
+	command := strings.Join(moduleOptions.TerraformCliArgs, " ")
+	moduleOptions.Logger.Printf("Running 'terragrunt %s' in %s", command, module.Path)
+
+	if err := runTerragrunt(moduleOptions); err != nil {
+		moduleOptions.Logger.Printf("Error running 'terragrunt %s' in %s: %s", command, module.Path, err)
+		return ModuleResult{Module: module, Status: MODULE_FAILED, Err: err}
+	}
+
+	return ModuleResult{Module: module, Status: MODULE_SUCCEEDED}
+}
+
+// Write out any unfinished lines in the prefixed writers of the given options. Log messages always end in a newline,
+// so the logger has nothing to flush.
+func flushOutput(moduleOptions *options.TerragruntOptions) {
+	for _, writer := range []io.Writer{moduleOptions.Writer, moduleOptions.ErrWriter} {
+		if prefixed, isPrefixWriter := writer.(*prefixWriter); isPrefixWriter {
+			prefixed.Flush()
+		}
+	}
+}
+
+// Return the dependencies of the given module
+func dependenciesOf(module *TerraformModule) []*TerraformModule {
+	return module.Dependencies
+}
+
+// Return a function that returns the modules, out of the given ones, that depend on a module
+func dependentsOf(modules []*TerraformModule) func(*TerraformModule) []*TerraformModule {
+	dependents := map[*TerraformModule][]*TerraformModule{}
+
+	for _, module := range modules {
+		for _, dependency := range module.Dependencies {
+			dependents[dependency] = append(dependents[dependency], module)
+		}
+	}
+
+	return func(module *TerraformModule) []*TerraformModule {
+		return dependents[module]
+	}
+}
+
+// Return the modules of this stack in the order Run runs them: dependency order or, if reverse is true, reverse
+// dependency order
+func (stack *Stack) ModulesInRunOrder(reverse bool) []*TerraformModule {
+	if !reverse {
+		return stack.Modules
+	}
+
+	reversed := []*TerraformModule{}
+	for i := len(stack.Modules) - 1; i >= 0; i-- {
+		reversed = append(reversed, stack.Modules[i])
+	}
+
+	return reversed
+}
+
+// Write a summary of the given results of running the given Terraform args to the given writer
+func writeSummary(results []ModuleResult, terraformArgs []string, writer io.Writer) {
+	fmt.Fprintf(writer, "\nSummary of 'terragrunt %s' in %d modules:\n", strings.Join(terraformArgs, " "), len(results))
+
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Status]++
+
+		if result.Err == nil {
+			fmt.Fprintf(writer, "  %s: %s\n", result.Module.Path, result.Status)
+		} else {
+			fmt.Fprintf(writer, "  %s: %s (%s)\n", result.Module.Path, result.Status, errors.Unwrap(result.Err))
+		}
+	}
+
+	fmt.Fprintf(writer, "%d succeeded, %d failed, %d skipped\n", counts[MODULE_SUCCEEDED], counts[MODULE_FAILED], counts[MODULE_SKIPPED])
+}
+
+// Return an error if any of the given results isn't a success
+func checkResults(results []ModuleResult) error {
+	err := StackRunFailed{}
+
+	for _, result := range results {
+		switch result.Status {
+		case MODULE_FAILED: err.Failed++
+		case MODULE_SKIPPED: err.Skipped++
+		}
+	}
+
+	if err.Failed > 0 || err.Skipped > 0 {
+		return errors.WithStackTrace(err)
+	}
+
+	return nil
+}
+
+type DependencyCycle string
+
+func (cycle DependencyCycle) Error() string {
+	return fmt.Sprintf("Found a dependency cycle between modules: %s", string(cycle))
+}
+
+type PrerequisiteFailed string
+
+func (path PrerequisiteFailed) Error() string {
+	return fmt.Sprintf("%s did not succeed", string(path))
+}
+
+type StackRunFailed struct {
+	Failed  int
+	Skipped int
+}
+
+func (err StackRunFailed) Error() string {
+	return fmt.Sprintf("The command failed in %d modules and was skipped in %d modules", err.Failed, err.Skipped)
+}
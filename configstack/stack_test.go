@@ -0,0 +1,212 @@
+package configstack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+func TestSortModules(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	redis := testModule("redis", vpc)
+	app := testModule("app", mysql, redis)
+
+	sorted, err := sortModules([]*TerraformModule{app, mysql, redis, vpc})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"vpc", "mysql", "redis", "app"}, modulePaths(sorted))
+}
+
+func TestSortModulesCycle(t *testing.T) {
+	t.Parallel()
+
+	a := testModule("a")
+	b := testModule("b", a)
+	c := testModule("c", b)
+	a.Dependencies = []*TerraformModule{c}
+
+	_, err := sortModules([]*TerraformModule{a, b, c})
+	assert.True(t, errors.IsError(err, DependencyCycle("a -> c -> b -> a")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestStackRun(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	app := testModule("app", mysql)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, app}}
+
+	runner := &testRunner{failures: map[string]bool{}}
+	out := &bytes.Buffer{}
+
+	err := stack.Run([]string{"apply"}, false, 1, runner.run, out)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"apply in vpc", "apply in mysql", "apply in app"}, runner.runs)
+	assert.Contains(t, out.String(), "3 succeeded, 0 failed, 0 skipped")
+}
+
+func TestStackRunReverse(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	app := testModule("app", mysql)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, app}}
+
+	runner := &testRunner{failures: map[string]bool{}}
+
+	err := stack.Run([]string{"destroy", "-force"}, true, 1, runner.run, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"destroy -force in app", "destroy -force in mysql", "destroy -force in vpc"}, runner.runs)
+}
+
+func TestStackRunSkipsDependentsOfFailedModules(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	redis := testModule("redis", vpc)
+	app := testModule("app", mysql)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, redis, app}}
+
+	runner := &testRunner{failures: map[string]bool{"mysql": true}}
+	out := &bytes.Buffer{}
+
+	err := stack.Run([]string{"apply"}, false, 1, runner.run, out)
+	assert.True(t, errors.IsError(err, StackRunFailed{Failed: 1, Skipped: 1}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	assert.Equal(t, []string{"apply in vpc", "apply in mysql", "apply in redis"}, runner.runs)
+	assert.Contains(t, out.String(), "app: skipped (mysql did not succeed)")
+	assert.Contains(t, out.String(), "2 succeeded, 1 failed, 1 skipped")
+}
+
+func TestStackRunReverseSkipsDependenciesOfFailedModules(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	redis := testModule("redis", vpc)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, redis}}
+
+	runner := &testRunner{failures: map[string]bool{"redis": true}}
+
+	err := stack.Run([]string{"destroy"}, true, 1, runner.run, &bytes.Buffer{})
+	assert.True(t, errors.IsError(err, StackRunFailed{Failed: 1, Skipped: 1}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	assert.Equal(t, []string{"destroy in redis", "destroy in mysql"}, runner.runs)
+}
+
+func TestStackRunParallel(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	redis := testModule("redis", vpc)
+	cache := testModule("cache", vpc)
+	app := testModule("app", mysql, redis)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, redis, cache, app}}
+
+	runner := &testRunner{failures: map[string]bool{}, delay: 20 * time.Millisecond}
+
+	err := stack.Run([]string{"apply"}, false, 2, runner.run, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, runner.maxRunning)
+	assert.Equal(t, 5, len(runner.runs))
+	assert.Equal(t, "apply in vpc", runner.runs[0])
+	assert.True(t, indexOf(runner.runs, "apply in app") > indexOf(runner.runs, "apply in mysql"), "Ran app before mysql: %v", runner.runs)
+	assert.True(t, indexOf(runner.runs, "apply in app") > indexOf(runner.runs, "apply in redis"), "Ran app before redis: %v", runner.runs)
+}
+
+func TestStackRunParallelSkipsDependentsOfFailedModules(t *testing.T) {
+	t.Parallel()
+
+	vpc := testModule("vpc")
+	mysql := testModule("mysql", vpc)
+	redis := testModule("redis", vpc)
+	app := testModule("app", mysql)
+	stack := &Stack{Modules: []*TerraformModule{vpc, mysql, redis, app}}
+
+	runner := &testRunner{failures: map[string]bool{"mysql": true}}
+	out := &bytes.Buffer{}
+
+	err := stack.Run([]string{"apply"}, false, 3, runner.run, out)
+	assert.True(t, errors.IsError(err, StackRunFailed{Failed: 1, Skipped: 1}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	assert.NotContains(t, runner.runs, "apply in app")
+	assert.Contains(t, out.String(), "app: skipped (mysql did not succeed)")
+}
+
+func TestStackRunParallelPrefixesOutput(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	stack := &Stack{Path: "/stack", Modules: []*TerraformModule{testModule("/stack/vpc"), testModule("/stack/mysql")}}
+	for _, module := range stack.Modules {
+		module.TerragruntOptions.Writer = out
+		module.TerragruntOptions.ErrWriter = out
+	}
+
+	runTerragrunt := func(terragruntOptions *options.TerragruntOptions) error {
+		fmt.Fprintf(terragruntOptions.Writer, "output of %s\nno newline", terragruntOptions.WorkingDir)
+		return nil
+	}
+
+	err := stack.Run([]string{"plan"}, false, 2, runTerragrunt, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "[vpc] output of /stack/vpc\n[vpc] no newline\n")
+	assert.Contains(t, out.String(), "[mysql] output of /stack/mysql\n[mysql] no newline\n")
+}
+
+// Records the modules it runs in and fails in the modules it's told to. If it's given a delay, it waits that long in
+// each module and records the largest number of modules it ran in at the same time.
+type testRunner struct {
+	failures   map[string]bool
+	delay      time.Duration
+	runs       []string
+	running    int
+	maxRunning int
+	mutex      sync.Mutex
+}
+
+func (runner *testRunner) run(terragruntOptions *options.TerragruntOptions) error {
+	runner.mutex.Lock()
+	runner.running++
+	if runner.running > runner.maxRunning {
+		runner.maxRunning = runner.running
+	}
+	runner.mutex.Unlock()
+
+	time.Sleep(runner.delay)
+
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	runner.running--
+
+	runner.runs = append(runner.runs, fmt.Sprintf("%s in %s", strings.Join(terragruntOptions.TerraformCliArgs, " "), terragruntOptions.WorkingDir))
+	if runner.failures[terragruntOptions.WorkingDir] {
+		return fmt.Errorf("%s failed", terragruntOptions.WorkingDir)
+	}
+	return nil
+}
+
+func indexOf(list []string, item string) int {
+	for i, element := range list {
+		if element == item {
+			return i
+		}
+	}
+	return -1
+}
+
+func testModule(path string, dependencies ... *TerraformModule) *TerraformModule {
+	terragruntOptions := options.NewTerragruntOptionsForTest(path + "/.terragrunt")
+	terragruntOptions.WorkingDir = path
+	return &TerraformModule{Path: path, Dependencies: dependencies, TerragruntOptions: terragruntOptions}
+}
@@ -2,20 +2,27 @@ package dynamodb
 
 import (
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/gruntwork-io/terragrunt/errors"
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gruntwork-io/terragrunt/options"
+	"sync"
 )
 
+// DynamoDB clients are safe for concurrent use, so Terragrunt creates one AWS session and one client per region, and
+// shares them between all the locks it acquires, e.g. in the modules of an apply-all that run in parallel
+var awsSession *session.Session
+var dynamoDbClients = map[string]*dynamodb.DynamoDB{}
+var dynamoDbClientsMutex sync.Mutex
+
 // A lock that uses AWS's DynamoDB to acquire and release locks
 type DynamoDbLock struct {
-	StateFileId 	string
-	AwsRegion   	string
-	TableName   	string
-	MaxLockRetries	int
+	StateFileId    string
+	AwsRegion      string
+	TableName      string
+	MaxLockRetries int
 }
 
 // Fill in default configuration values for this lock
@@ -43,27 +50,27 @@ func (dynamoDbLock *DynamoDbLock) Validate() error {
 }
 
 // Acquire a lock by writing an entry to DynamoDB. If that write fails, it means someone else already has the lock, so
-// retry until they release the lock.
-func (dynamoDbLock DynamoDbLock) AcquireLock() error {
-	util.Logger.Printf("Attempting to acquire lock for state file %s in DynamoDB", dynamoDbLock.StateFileId)
+// retry until they release the lock. Progress is logged with the logger of the given options.
+func (dynamoDbLock DynamoDbLock) AcquireLock(terragruntOptions *options.TerragruntOptions) error {
+	terragruntOptions.Logger.Printf("Attempting to acquire lock for state file %s in DynamoDB", dynamoDbLock.StateFileId)
 
-	client, err := createDynamoDbClient(dynamoDbLock.AwsRegion)
+	client, err := getDynamoDbClient(dynamoDbLock.AwsRegion)
 	if err != nil {
 		return err
 	}
 
-	if err := createLockTableIfNecessary(dynamoDbLock.TableName, client); err != nil {
+	if err := createLockTableIfNecessary(dynamoDbLock.TableName, client, terragruntOptions); err != nil {
 		return err
 	}
 
-	return writeItemToLockTableUntilSuccess(dynamoDbLock.StateFileId, dynamoDbLock.TableName, client, dynamoDbLock.MaxLockRetries, SLEEP_BETWEEN_TABLE_LOCK_ACQUIRE_ATTEMPTS)
+	return writeItemToLockTableUntilSuccess(dynamoDbLock.StateFileId, dynamoDbLock.TableName, client, dynamoDbLock.MaxLockRetries, SLEEP_BETWEEN_TABLE_LOCK_ACQUIRE_ATTEMPTS, terragruntOptions)
 }
 
-// Release a lock by deleting an entry from DynamoDB.
-func (dynamoDbLock DynamoDbLock) ReleaseLock() error {
-	util.Logger.Printf("Attempting to release lock for state file %s in DynamoDB", dynamoDbLock.StateFileId)
+// Release a lock by deleting an entry from DynamoDB. Progress is logged with the logger of the given options.
+func (dynamoDbLock DynamoDbLock) ReleaseLock(terragruntOptions *options.TerragruntOptions) error {
+	terragruntOptions.Logger.Printf("Attempting to release lock for state file %s in DynamoDB", dynamoDbLock.StateFileId)
 
-	client, err := createDynamoDbClient(dynamoDbLock.AwsRegion)
+	client, err := getDynamoDbClient(dynamoDbLock.AwsRegion)
 	if err != nil {
 		return err
 	}
@@ -72,7 +79,7 @@ func (dynamoDbLock DynamoDbLock) ReleaseLock() error {
 		return err
 	}
 
-	util.Logger.Printf("Lock released!")
+	terragruntOptions.Logger.Printf("Lock released!")
 	return nil
 }
 
@@ -81,14 +88,37 @@ func (dynamoLock DynamoDbLock) String() string {
 	return fmt.Sprintf("DynamoDB lock for state file %s", dynamoLock.StateFileId)
 }
 
-// Create an authenticated client for DynamoDB
+// Return the shared authenticated client for DynamoDB in the given region, creating it if it doesn't exist yet
+func getDynamoDbClient(awsRegion string) (*dynamodb.DynamoDB, error) {
+	dynamoDbClientsMutex.Lock()
+	defer dynamoDbClientsMutex.Unlock()
+
+	if client, found := dynamoDbClients[awsRegion]; found {
+		return client, nil
+	}
+
+	client, err := createDynamoDbClient(awsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamoDbClients[awsRegion] = client
+	return client, nil
+}
+
+// Create an authenticated client for DynamoDB that uses the shared AWS session. Callers must hold the
+// dynamoDbClientsMutex.
 func createDynamoDbClient(awsRegion string) (*dynamodb.DynamoDB, error) {
 	config, err := createAwsConfig(awsRegion)
 	if err != nil {
 		return nil, err
 	}
 
-	return dynamodb.New(session.New(), config), nil
+	if awsSession == nil {
+		awsSession = session.New()
+	}
+
+	return dynamodb.New(awsSession, config), nil
 }
 
 // Returns an AWS config object for the given region, ensuring that the config has credentials
@@ -104,5 +134,3 @@ func createAwsConfig(awsRegion string) (*aws.Config, error) {
 }
 
 var StateFileIdMissing = fmt.Errorf("The dynamodb.stateFileId field cannot be empty")
-
-
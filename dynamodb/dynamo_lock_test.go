@@ -8,6 +8,7 @@ import (
 	"github.com/gruntwork-io/terragrunt/errors"
 	"reflect"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/gruntwork-io/terragrunt/options"
 )
 
 func TestAcquireLockHappyPath(t *testing.T) {
@@ -23,7 +24,7 @@ func TestAcquireLockHappyPath(t *testing.T) {
 
 	defer cleanupTable(t, lock.TableName, client)
 
-	err := lock.AcquireLock()
+	err := lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 }
 
@@ -42,11 +43,11 @@ func TestAcquireLockWhenLockIsAlreadyTaken(t *testing.T) {
 	defer cleanupTable(t, lock.TableName, client)
 
 	// Acquire the lock the first time
-	err := lock.AcquireLock()
+	err := lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 
 	// Now try to acquire the lock again and make sure you get an error
-	err = lock.AcquireLock()
+	err = lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.True(t, errors.IsError(err, AcquireLockRetriesExceeded{ItemId: stateFileId, Retries: 1}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
 }
 
@@ -65,19 +66,19 @@ func TestAcquireAndReleaseLock(t *testing.T) {
 	defer cleanupTable(t, lock.TableName, client)
 
 	// Acquire the lock the first time
-	err := lock.AcquireLock()
+	err := lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 
 	// Now try to acquire the lock again and make sure you get an error
-	err = lock.AcquireLock()
+	err = lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.True(t, errors.IsError(err, AcquireLockRetriesExceeded{ItemId: stateFileId, Retries: 1}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
 
 	// Release the lock
-	err = lock.ReleaseLock()
+	err = lock.ReleaseLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 
 	// Finally, try to acquire the lock again; you should succeed
-	err = lock.AcquireLock()
+	err = lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 }
 
@@ -107,7 +108,7 @@ func TestAcquireLockConcurrency(t *testing.T) {
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				err := lock.AcquireLock()
+				err := lock.AcquireLock(options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 				if err == nil {
 					atomic.AddInt32(&locksAcquired, 1)
 				} else {
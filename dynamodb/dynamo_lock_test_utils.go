@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/aws/aws-sdk-go/aws"
 	"fmt"
+	"github.com/gruntwork-io/terragrunt/options"
 )
 
 // For simplicity, do all testing in the us-east-1 region
@@ -33,7 +34,7 @@ func uniqueId() string {
 
 // Create a DynamoDB client we can use at test time. If there are any errors creating the client, fail the test.
 func createDynamoDbClientForTest(t *testing.T) *dynamodb.DynamoDB {
-	client, err := createDynamoDbClient(DEFAULT_TEST_REGION)
+	client, err := getDynamoDbClient(DEFAULT_TEST_REGION)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -86,7 +87,7 @@ func withLockTable(t *testing.T, action func(tableName string, client *dynamodb.
 	client := createDynamoDbClientForTest(t)
 	tableName := uniqueTableNameForTest()
 
-	err := createLockTableIfNecessary(tableName, client)
+	err := createLockTableIfNecessary(tableName, client, options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 	defer cleanupTable(t, tableName, client)
 
@@ -97,7 +98,7 @@ func withLockTableProvisionedUnits(t *testing.T, readCapacityUnits int, writeCap
 	client := createDynamoDbClientForTest(t)
 	tableName := uniqueTableNameForTest()
 
-	err := createLockTable(tableName, readCapacityUnits, writeCapacityUnits, client)
+	err := createLockTable(tableName, readCapacityUnits, writeCapacityUnits, client, options.NewTerragruntOptionsForTest("dynamo_lock_test"))
 	assert.Nil(t, err)
 	defer cleanupTable(t, tableName, client)
 
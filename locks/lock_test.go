@@ -4,26 +4,27 @@ import (
 	"testing"
 	"github.com/stretchr/testify/assert"
 	"fmt"
+	"github.com/gruntwork-io/terragrunt/options"
 )
 
 // A mock lock that performs a No Op for every operation
 type NoopLock struct {}
-func (lock NoopLock) AcquireLock() error { return nil }
-func (lock NoopLock) ReleaseLock() error { return nil }
+func (lock NoopLock) AcquireLock(terragruntOptions *options.TerragruntOptions) error { return nil }
+func (lock NoopLock) ReleaseLock(terragruntOptions *options.TerragruntOptions) error { return nil }
 func (lock NoopLock) String() string { return "MockLock" }
 
 func TestWithLockNoop(t *testing.T) {
 	t.Parallel()
 
-	err := WithLock(NoopLock{}, func() error { return nil })
+	err := WithLock(NoopLock{}, options.NewTerragruntOptionsForTest("lock_test"), func() error { return nil })
 	assert.Nil(t, err)
 }
 
 // A mock lock that returns an error on AcquireLock
 type ErrorOnAcquireLock struct {}
 var ErrorOnAcquire = fmt.Errorf("error-on-acquire")
-func (lock ErrorOnAcquireLock) AcquireLock() error { return ErrorOnAcquire }
-func (lock ErrorOnAcquireLock) ReleaseLock() error { return nil }
+func (lock ErrorOnAcquireLock) AcquireLock(terragruntOptions *options.TerragruntOptions) error { return ErrorOnAcquire }
+func (lock ErrorOnAcquireLock) ReleaseLock(terragruntOptions *options.TerragruntOptions) error { return nil }
 func (lock ErrorOnAcquireLock) String() string { return "ErrorOnAcquireLock" }
 
 func TestWithLockErrorOnAcquire(t *testing.T) {
@@ -31,7 +32,7 @@ func TestWithLockErrorOnAcquire(t *testing.T) {
 
 	actionDidExecute := false
 
-	err := WithLock(ErrorOnAcquireLock{}, func() error {
+	err := WithLock(ErrorOnAcquireLock{}, options.NewTerragruntOptionsForTest("lock_test"), func() error {
 		actionDidExecute = true
 		return nil
 	})
@@ -43,8 +44,8 @@ func TestWithLockErrorOnAcquire(t *testing.T) {
 // A mock lock that returns an error on Release
 type ErrorOnReleaseLock struct {}
 var ErrorOnRelease = fmt.Errorf("error-on-release")
-func (lock ErrorOnReleaseLock) AcquireLock() error { return nil }
-func (lock ErrorOnReleaseLock) ReleaseLock() error { return ErrorOnRelease }
+func (lock ErrorOnReleaseLock) AcquireLock(terragruntOptions *options.TerragruntOptions) error { return nil }
+func (lock ErrorOnReleaseLock) ReleaseLock(terragruntOptions *options.TerragruntOptions) error { return ErrorOnRelease }
 func (lock ErrorOnReleaseLock) String() string { return "ErrorOnRelease" }
 
 func TestWithLockErrorOnRelease(t *testing.T) {
@@ -52,7 +53,7 @@ func TestWithLockErrorOnRelease(t *testing.T) {
 
 	actionDidExecute := false
 
-	err := WithLock(ErrorOnReleaseLock{}, func() error {
+	err := WithLock(ErrorOnReleaseLock{}, options.NewTerragruntOptionsForTest("lock_test"), func() error {
 		actionDidExecute = true
 		return nil
 	})
@@ -67,7 +68,7 @@ func TestWithLockErrorOnReleaseAndErrorInAction(t *testing.T) {
 	actionDidExecute := false
 	actionErr := fmt.Errorf("error-in-action")
 
-	err := WithLock(ErrorOnReleaseLock{}, func() error {
+	err := WithLock(ErrorOnReleaseLock{}, options.NewTerragruntOptionsForTest("lock_test"), func() error {
 		actionDidExecute = true
 		return actionErr
 	})
@@ -87,7 +88,7 @@ func TestWithLockErrorOnReleaseAndPanicInAction(t *testing.T) {
 	actionDidExecute := false
 	actionErr := fmt.Errorf("error-in-action")
 
-	err := WithLock(ErrorOnReleaseLock{}, func() error {
+	err := WithLock(ErrorOnReleaseLock{}, options.NewTerragruntOptionsForTest("lock_test"), func() error {
 		actionDidExecute = true
 		panic(actionErr)
 	})
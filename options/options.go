@@ -0,0 +1,209 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"github.com/hashicorp/go-version"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Terragrunt downloads Terraform sources into this folder in the Terragrunt home dir (e.g. ~/.terragrunt/sources)
+const DOWNLOAD_DIR_NAME = "sources"
+
+// Terragrunt looks for Terraform binaries of specific versions in this folder in the Terragrunt home dir, e.g.
+// ~/.terragrunt/terraform/0.9.3/terraform
+const TERRAFORM_VERSIONS_DIR_NAME = "terraform"
+
+// By default, Terragrunt runs terraform from the PATH
+const DEFAULT_TERRAFORM_PATH = "terraform"
+
+// By default, multi-module commands such as apply-all run in one module at a time
+const DEFAULT_PARALLELISM = 1
+
+// Terraform doesn't ask for input if this environment variable is 0 or false. Terragrunt doesn't either, and always
+// sets it to 0 for the commands it runs when NonInteractive is set.
+const TF_INPUT_ENV_VAR = "TF_INPUT"
+
+// Options that control how Terragrunt runs. The Terragrunt CLI creates an instance from the command-line args, but
+// other Go programs can create one with NewTerragruntOptions and pass it to cli.RunTerragrunt to run Terragrunt
+// without going through the command line.
+type TerragruntOptions struct {
+	// The path to the Terragrunt config file
+	TerragruntConfigPath string
+
+	// The command and arguments to pass to Terraform, e.g. ["plan", "-input=false"]
+	TerraformCliArgs     []string
+
+	// The folder in which to run Terraform, or an empty string for the current working directory
+	WorkingDir           string
+
+	// If set, use this Terraform source instead of the one in the Terragrunt config, e.g. a local checkout of the
+	// repository, while developing the Terraform templates
+	Source               string
+
+	// The folder into which Terragrunt downloads Terraform sources
+	DownloadDir          string
+
+	// The name of the environment block in the Terragrunt config whose settings to use, e.g. prod, or an empty string to
+	// use the settings outside of environment blocks
+	Environment          string
+
+	// The Terraform binary to run. If this is the default, terraform from the PATH, the Terragrunt config may choose a
+	// different one.
+	TerraformPath        string
+
+	// The folder in which Terragrunt looks for Terraform binaries of specific versions, each at <version>/terraform
+	TerraformVersionsDir string
+
+	// The version of the Terraform binary at TerraformPath, or nil if it hasn't been looked up yet. Terragrunt sets this
+	// the first time it runs "terraform version", so it only has to run it once. Reset it if you change TerraformPath.
+	TerraformVersion     *version.Version
+
+	// The environment variables Terragrunt looks up and passes to Terraform. If nil, the environment of the
+	// currently running process is used.
+	Env                  map[string]string
+
+	// The logger used for all the log messages Terragrunt itself writes
+	Logger               *log.Logger
+
+	// The version of Terragrunt that is running, which is checked against the terragruntVersionConstraint in the
+	// Terragrunt config
+	TerragruntVersion    string
+
+	// The maximum number of modules in which multi-module commands such as apply-all run at the same time
+	Parallelism          int
+
+	// If true, Terragrunt never waits for the user to answer a prompt, and runs Terraform with TF_INPUT=0
+	NonInteractive       bool
+
+	// Where Terraform and prompts read input from
+	Reader               io.Reader
+
+	// Where Terraform and Terragrunt commands write their output
+	Writer               io.Writer
+
+	// Where Terraform writes its errors
+	ErrWriter            io.Writer
+}
+
+// Create a new TerragruntOptions object for the Terragrunt config at the given path, with reasonable defaults: run in
+// the current working directory, use the environment of the currently running process, and connect to its stdin,
+// stdout, and stderr.
+func NewTerragruntOptions(terragruntConfigPath string) *TerragruntOptions {
+	return &TerragruntOptions{
+		TerragruntConfigPath: terragruntConfigPath,
+		TerraformCliArgs: []string{},
+		WorkingDir: "",
+		Source: "",
+		DownloadDir: defaultTerragruntHomeSubdir(DOWNLOAD_DIR_NAME),
+		TerraformPath: DEFAULT_TERRAFORM_PATH,
+		TerraformVersionsDir: defaultTerragruntHomeSubdir(TERRAFORM_VERSIONS_DIR_NAME),
+		Env: ParseEnvironmentVariables(os.Environ()),
+		Logger: util.Logger,
+		Parallelism: DEFAULT_PARALLELISM,
+		NonInteractive: false,
+		Reader: os.Stdin,
+		Writer: os.Stdout,
+		ErrWriter: os.Stderr,
+	}
+}
+
+// Return the folder with the given name in the Terragrunt home dir or, if the home dir can't be found, in the temp dir
+func defaultTerragruntHomeSubdir(name string) string {
+	terragruntHomeDir, err := util.GetTerragruntHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "terragrunt", name)
+	}
+
+	return filepath.Join(terragruntHomeDir, name)
+}
+
+// Create a new TerragruntOptions object for use in automated tests. It has an empty environment and does not read any
+// input.
+func NewTerragruntOptionsForTest(terragruntConfigPath string) *TerragruntOptions {
+	terragruntOptions := NewTerragruntOptions(terragruntConfigPath)
+	terragruntOptions.Env = map[string]string{}
+	terragruntOptions.NonInteractive = true
+	terragruntOptions.Reader = strings.NewReader("")
+	return terragruntOptions
+}
+
+// Create a copy of these options that can be modified without affecting the original. If these options use the
+// environment of the currently running process, the copy gets its own map with those environment variables.
+func (terragruntOptions *TerragruntOptions) Clone() *TerragruntOptions {
+	clone := *terragruntOptions
+
+	clone.TerraformCliArgs = append([]string{}, terragruntOptions.TerraformCliArgs...)
+
+	if terragruntOptions.Env == nil {
+		clone.Env = ParseEnvironmentVariables(os.Environ())
+	} else {
+		clone.Env = map[string]string{}
+		for name, value := range terragruntOptions.Env {
+			clone.Env[name] = value
+		}
+	}
+
+	return &clone
+}
+
+// Return the Terraform command (the first of the Terraform args), such as "plan" or "apply", or an empty string if
+// there are no Terraform args
+func (terragruntOptions *TerragruntOptions) TerraformCommand() string {
+	if len(terragruntOptions.TerraformCliArgs) == 0 {
+		return ""
+	}
+	return terragruntOptions.TerraformCliArgs[0]
+}
+
+// Return the value of the environment variable with the given name, or an empty string if it's not set
+func (terragruntOptions *TerragruntOptions) Getenv(name string) string {
+	if terragruntOptions.Env == nil {
+		return os.Getenv(name)
+	}
+	return terragruntOptions.Env[name]
+}
+
+// Return the environment variables in the NAME=value format used by os/exec, sorted by name, or nil if the
+// environment of the currently running process should be used. If NonInteractive is set, TF_INPUT is always 0, so
+// Terraform doesn't ask for input either.
+func (terragruntOptions *TerragruntOptions) EnvironmentList() []string {
+	env := terragruntOptions.Env
+
+	if terragruntOptions.NonInteractive {
+		env = terragruntOptions.Clone().Env
+		env[TF_INPUT_ENV_VAR] = "0"
+	}
+
+	if env == nil {
+		return nil
+	}
+
+	envList := []string{}
+	for name, value := range env {
+		envList = append(envList, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(envList)
+
+	return envList
+}
+
+// Parse the given environment variables, in the NAME=value format returned by os.Environ, into a map from name to
+// value
+func ParseEnvironmentVariables(environment []string) map[string]string {
+	env := map[string]string{}
+
+	for _, variable := range environment {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	return env
+}
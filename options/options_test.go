@@ -0,0 +1,82 @@
+package options
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvironmentVariables(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		environment []string
+		expected    map[string]string
+	}{
+		{[]string{}, map[string]string{}},
+		{[]string{"FOO=bar"}, map[string]string{"FOO": "bar"}},
+		{[]string{"FOO=bar", "BAZ="}, map[string]string{"FOO": "bar", "BAZ": ""}},
+		{[]string{"FOO=a=b"}, map[string]string{"FOO": "a=b"}},
+		{[]string{"INVALID"}, map[string]string{}},
+	}
+
+	for _, testCase := range testCases {
+		actual := ParseEnvironmentVariables(testCase.environment)
+		assert.Equal(t, testCase.expected, actual, "For environment %v", testCase.environment)
+	}
+}
+
+func TestEnvironmentList(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := NewTerragruntOptionsForTest(".terragrunt")
+	terragruntOptions.Env = map[string]string{"FOO": "bar", "BAZ": "a=b"}
+	terragruntOptions.NonInteractive = false
+
+	assert.Equal(t, []string{"BAZ=a=b", "FOO=bar"}, terragruntOptions.EnvironmentList())
+	assert.Equal(t, "bar", terragruntOptions.Getenv("FOO"))
+	assert.Equal(t, "", terragruntOptions.Getenv("NOT_SET"))
+}
+
+func TestEnvironmentListNonInteractive(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := NewTerragruntOptionsForTest(".terragrunt")
+	terragruntOptions.Env = map[string]string{"FOO": "bar", TF_INPUT_ENV_VAR: "1"}
+
+	terragruntOptions.NonInteractive = false
+	assert.Equal(t, []string{"FOO=bar", "TF_INPUT=1"}, terragruntOptions.EnvironmentList())
+
+	terragruntOptions.NonInteractive = true
+	assert.Equal(t, []string{"FOO=bar", "TF_INPUT=0"}, terragruntOptions.EnvironmentList())
+	assert.Equal(t, map[string]string{"FOO": "bar", TF_INPUT_ENV_VAR: "1"}, terragruntOptions.Env)
+
+	terragruntOptions.Env = nil
+	assert.Contains(t, terragruntOptions.EnvironmentList(), "TF_INPUT=0")
+}
+
+func TestTerraformCommand(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := NewTerragruntOptionsForTest(".terragrunt")
+	assert.Equal(t, "", terragruntOptions.TerraformCommand())
+
+	terragruntOptions.TerraformCliArgs = []string{"plan", "-input=false"}
+	assert.Equal(t, "plan", terragruntOptions.TerraformCommand())
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := NewTerragruntOptionsForTest(".terragrunt")
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+	terragruntOptions.Env = map[string]string{"FOO": "bar"}
+
+	clone := terragruntOptions.Clone()
+	clone.TerraformCliArgs[0] = "apply"
+	clone.Env["FOO"] = "baz"
+
+	assert.Equal(t, []string{"plan"}, terragruntOptions.TerraformCliArgs)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, terragruntOptions.Env)
+	assert.Equal(t, []string{"apply"}, clone.TerraformCliArgs)
+	assert.Equal(t, map[string]string{"FOO": "baz"}, clone.Env)
+}
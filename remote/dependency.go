@@ -0,0 +1,194 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Terragrunt writes the outputs of all dependencies to this file and passes it to Terraform with -var-file
+const DEPENDENCY_VAR_FILE = ".terraform/terragrunt-dependencies.tfvars"
+
+// Dependency names are used as a prefix for Terraform variable names, so they must be valid variable names themselves
+var DEPENDENCY_NAME_REGEX = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Another folder of Terraform templates whose outputs should be made available to the templates in the current
+// folder. Each output of the dependency is passed to Terraform as a variable named <name>_<output>. For example, the
+// vpc_id output of a dependency named vpc is passed in as the variable vpc_vpc_id.
+type Dependency struct {
+	Name string `hcl:",key"`
+	Path string
+}
+
+// Fill in any default configuration for the dependency
+func (dependency *Dependency) FillDefaults() {
+	// Nothing to do
+}
+
+// Validate that the dependency is configured correctly
+func (dependency *Dependency) Validate() error {
+	if !DEPENDENCY_NAME_REGEX.MatchString(dependency.Name) {
+		return errors.WithStackTrace(InvalidDependencyName(dependency.Name))
+	}
+
+	if dependency.Path == "" {
+		return errors.WithStackTrace(DependencyPathMissing(dependency.Name))
+	}
+
+	return nil
+}
+
+// Validate that each of the given dependencies is configured correctly and that no two have the same name
+func ValidateDependencies(dependencies []Dependency) error {
+	names := map[string]bool{}
+
+	for _, dependency := range dependencies {
+		if err := dependency.Validate(); err != nil {
+			return err
+		}
+
+		if names[dependency.Name] {
+			return errors.WithStackTrace(DuplicateDependencyName(dependency.Name))
+		}
+		names[dependency.Name] = true
+	}
+
+	return nil
+}
+
+// Read the outputs of each of the given dependencies, using the given function to find the folder Terraform runs in for
+// each dependency and the given options, and write them, as Terraform variables, to the var file at the given path.
+// The var file uses JSON syntax, which Terraform accepts in place of HCL. Since outputs may contain secrets, the var
+// file is only readable by the current user.
+func WriteDependencyVarFile(dependencies []Dependency, varFilePath string, findTerraformDir func(dependencyDir string) (string, error), terragruntOptions *options.TerragruntOptions) error {
+	variables := map[string]interface{}{}
+
+	for _, dependency := range dependencies {
+		outputs, err := dependency.ReadOutputs(findTerraformDir, terragruntOptions)
+		if err != nil {
+			return err
+		}
+
+		for name, value := range dependencyVariables(dependency.Name, outputs) {
+			variables[name] = value
+		}
+	}
+
+	return writeVarFile(variables, varFilePath)
+}
+
+// Read the outputs of this dependency by running "terraform output -json" with the given options in the folder
+// Terraform runs in for the dependency. The given function returns that folder for the folder of the dependency, which
+// differs if the dependency downloads its templates from a Terraform source. A relative path is relative to the working
+// directory of the options.
+func (dependency Dependency) ReadOutputs(findTerraformDir func(dependencyDir string) (string, error), terragruntOptions *options.TerragruntOptions) (map[string]interface{}, error) {
+	dependencyDir := joinWithWorkingDir(terragruntOptions.WorkingDir, dependency.Path)
+	if !util.FileExists(dependencyDir) {
+		return nil, errors.WithStackTrace(DependencyPathNotFound{Name: dependency.Name, Path: dependencyDir})
+	}
+
+	terraformDir, err := findTerraformDir(dependencyDir)
+	if err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error finding the Terraform folder of dependency %s in %s", dependency.Name, dependencyDir)
+	}
+	if !util.FileExists(terraformDir) {
+		return nil, errors.WithStackTrace(DependencySourceNotDownloaded{Name: dependency.Name, Path: dependencyDir, TerraformDir: terraformDir})
+	}
+
+	terragruntOptions.Logger.Printf("Reading outputs of dependency %s from %s", dependency.Name, terraformDir)
+
+	dependencyOptions := terragruntOptions.Clone()
+	dependencyOptions.WorkingDir = terraformDir
+
+	out, err := shell.RunShellCommandWithOptionsAndCaptureOutput(dependencyOptions, terragruntOptions.TerraformPath, "output", "-json")
+	if err != nil {
+		return nil, errors.WithStackTraceAndPrefix(err, "Error reading outputs of dependency %s from %s", dependency.Name, terraformDir)
+	}
+
+	return parseTerraformOutputJson(out)
+}
+
+// Parse the output of "terraform output -json", which maps each output name to an object with "sensitive", "type",
+// and "value" fields, into a map from output name to output value
+func parseTerraformOutputJson(out []byte) (map[string]interface{}, error) {
+	rawOutputs := map[string]struct{ Value interface{} }{}
+	if err := json.Unmarshal(out, &rawOutputs); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	outputs := map[string]interface{}{}
+	for name, rawOutput := range rawOutputs {
+		outputs[name] = rawOutput.Value
+	}
+
+	return outputs, nil
+}
+
+// Return the Terraform variables for the given outputs of the dependency with the given name
+func dependencyVariables(dependencyName string, outputs map[string]interface{}) map[string]interface{} {
+	variables := map[string]interface{}{}
+
+	for name, value := range outputs {
+		variables[fmt.Sprintf("%s_%s", dependencyName, name)] = value
+	}
+
+	return variables
+}
+
+// Write the given Terraform variables to a var file at the given path
+func writeVarFile(variables map[string]interface{}, varFilePath string) error {
+	bytes, err := json.MarshalIndent(variables, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(varFilePath), 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return errors.WithStackTrace(ioutil.WriteFile(varFilePath, bytes, 0600))
+}
+
+type InvalidDependencyName string
+
+func (name InvalidDependencyName) Error() string {
+	return fmt.Sprintf("Invalid dependency name '%s'. Dependency names may only contain letters, digits, and underscores, and may not start with a digit.", string(name))
+}
+
+type DependencyPathMissing string
+
+func (name DependencyPathMissing) Error() string {
+	return fmt.Sprintf("The path parameter must be specified for dependency %s", string(name))
+}
+
+type DuplicateDependencyName string
+
+func (name DuplicateDependencyName) Error() string {
+	return fmt.Sprintf("There is more than one dependency named %s", string(name))
+}
+
+type DependencyPathNotFound struct {
+	Name string
+	Path string
+}
+
+func (err DependencyPathNotFound) Error() string {
+	return fmt.Sprintf("The path %s for dependency %s does not exist", err.Path, err.Name)
+}
+
+type DependencySourceNotDownloaded struct {
+	Name         string
+	Path         string
+	TerraformDir string
+}
+
+func (err DependencySourceNotDownloaded) Error() string {
+	return fmt.Sprintf("Dependency %s in %s downloads its templates from a Terraform source into %s, which doesn't exist yet. Run Terragrunt in %s first.", err.Name, err.Path, err.TerraformDir, err.Path)
+}
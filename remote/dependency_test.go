@@ -0,0 +1,118 @@
+package remote
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+func TestParseTerraformOutputJson(t *testing.T) {
+	t.Parallel()
+
+	out :=
+	`
+	{
+		"vpc_id": {"sensitive": false, "type": "string", "value": "vpc-123456"},
+		"subnet_ids": {"sensitive": false, "type": "list", "value": ["subnet-1", "subnet-2"]},
+		"tags": {"sensitive": false, "type": "map", "value": {"Name": "main"}}
+	}
+	`
+
+	outputs, err := parseTerraformOutputJson([]byte(out))
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"vpc_id": "vpc-123456",
+		"subnet_ids": []interface{}{"subnet-1", "subnet-2"},
+		"tags": map[string]interface{}{"Name": "main"},
+	}, outputs)
+}
+
+func TestParseTerraformOutputJsonInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseTerraformOutputJson([]byte("The state file has no outputs defined"))
+	assert.NotNil(t, err)
+}
+
+func TestDependencyVariables(t *testing.T) {
+	t.Parallel()
+
+	variables := dependencyVariables("vpc", map[string]interface{}{"vpc_id": "vpc-123456", "cidr_block": "10.0.0.0/16"})
+	assert.Equal(t, map[string]interface{}{"vpc_vpc_id": "vpc-123456", "vpc_cidr_block": "10.0.0.0/16"}, variables)
+}
+
+func TestValidateDependencies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		dependencies []Dependency
+		expectedErr  error
+	}{
+		{[]Dependency{}, nil},
+		{[]Dependency{{Name: "vpc", Path: "../vpc"}, {Name: "mysql_db", Path: "../mysql"}}, nil},
+		{[]Dependency{{Name: "vpc"}}, DependencyPathMissing("vpc")},
+		{[]Dependency{{Name: "my-vpc", Path: "../vpc"}}, InvalidDependencyName("my-vpc")},
+		{[]Dependency{{Name: "1vpc", Path: "../vpc"}}, InvalidDependencyName("1vpc")},
+		{[]Dependency{{Name: "vpc", Path: "../vpc"}, {Name: "vpc", Path: "../other"}}, DuplicateDependencyName("vpc")},
+	}
+
+	for _, testCase := range testCases {
+		err := ValidateDependencies(testCase.dependencies)
+		if testCase.expectedErr == nil {
+			assert.Nil(t, err, "For dependencies %v", testCase.dependencies)
+		} else {
+			assert.True(t, errors.IsError(err, testCase.expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		}
+	}
+}
+
+func TestReadOutputsPathNotFound(t *testing.T) {
+	t.Parallel()
+
+	dependency := Dependency{Name: "vpc", Path: "/this/path/does/not/exist"}
+	_, err := dependency.ReadOutputs(sameDir, options.NewTerragruntOptionsForTest("dependency_test"))
+	assert.True(t, errors.IsError(err, DependencyPathNotFound{Name: "vpc", Path: "/this/path/does/not/exist"}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestReadOutputsSourceNotDownloaded(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	downloadDir := filepath.Join(tmpDir, "download", "vpc")
+	findDownloadDir := func(dependencyDir string) (string, error) { return downloadDir, nil }
+
+	dependency := Dependency{Name: "vpc", Path: tmpDir}
+	_, err := dependency.ReadOutputs(findDownloadDir, options.NewTerragruntOptionsForTest("dependency_test"))
+	assert.True(t, errors.IsError(err, DependencySourceNotDownloaded{Name: "vpc", Path: tmpDir, TerraformDir: downloadDir}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestWriteVarFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	varFilePath := filepath.Join(tmpDir, ".terraform", "terragrunt-dependencies.tfvars")
+	err := writeVarFile(map[string]interface{}{"vpc_vpc_id": "vpc-123456", "vpc_subnet_ids": []interface{}{"subnet-1"}}, varFilePath)
+	assert.Nil(t, err)
+
+	bytes, err := ioutil.ReadFile(varFilePath)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"vpc_vpc_id": "vpc-123456", "vpc_subnet_ids": ["subnet-1"]}`, string(bytes))
+
+	info, err := os.Stat(varFilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func sameDir(dependencyDir string) (string, error) {
+	return dependencyDir, nil
+}
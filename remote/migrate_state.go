@@ -0,0 +1,229 @@
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+)
+
+// Before switching the current folder to a new backend, the cached copy of the state is moved to a file with this suffix
+const PRE_MIGRATION_STATE_FILE_SUFFIX = ".pre-migration"
+
+// Migrate the Terraform state from the remote backend it is currently stored in to the backend described by this
+// remote state config. The migration works as follows:
+//
+// 1. In a temp folder, pull the latest state from the old backend and verify it parses correctly.
+// 2. In another temp folder, configure the new backend and check it doesn't already hold the state of different
+//    templates.
+// 3. Push the state to the new backend, pull it back down, and verify its serial and lineage.
+// 4. Only then, switch the current folder over to the new backend.
+//
+// The given paths are used to find the state in the current folder, and Terraform runs with the given options. If dryRun
+// is true, only steps 1 and 2 are executed, and the rest of the plan is logged. As both of those steps run in temp
+// folders, a dry run doesn't change anything in the current folder.
+func (remoteState RemoteState) MigrateRemoteState(dryRun bool, statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	currentState, err := statePaths.ParseStateFile()
+	if err != nil {
+		return err
+	}
+
+	if currentState != nil && currentState.IsBackend() {
+		return errors.WithStackTrace(UseTerraformInitToMigrateState)
+	}
+
+	if currentState == nil || !currentState.IsRemote() {
+		return errors.WithStackTrace(NoRemoteStateToMigrate)
+	}
+
+	if remoteStateMatches(currentState.Remote, remoteState) {
+		terragruntOptions.Logger.Printf("Remote state is already configured for backend %s with the settings in your Terragrunt configuration, so there is nothing to migrate", remoteState.Backend)
+		return nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "terragrunt-migrate-state")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sourceDirOptions := migrationDirOptions(filepath.Join(tmpDir, "source"), terragruntOptions)
+	sourceStatePath := filepath.Join(sourceDirOptions.WorkingDir, DEFAULT_PATH_TO_REMOTE_STATE_FILE)
+
+	if err := copyStateFile(statePaths.RemoteStateFile, sourceStatePath); err != nil {
+		return err
+	}
+
+	if err := shell.RunShellCommandWithOptions(sourceDirOptions, terragruntOptions.TerraformPath, "remote", "pull"); err != nil {
+		return err
+	}
+
+	sourceStateData, sourceState, err := readStateFile(sourceStatePath)
+	if err != nil {
+		return err
+	}
+
+	if !sourceState.IsRemote() || sourceState.Remote.Type != currentState.Remote.Type {
+		return errors.WithStackTrace(UnexpectedStateAfterPull{Path: sourceStatePath})
+	}
+
+	terragruntOptions.Logger.Printf("Migrating state with serial %d and lineage %s from backend %s to backend %s", sourceState.Serial, sourceState.Lineage, sourceState.Remote.Type, remoteState.Backend)
+
+	tmpDirOptions := migrationDirOptions(filepath.Join(tmpDir, "destination"), terragruntOptions)
+	destinationStatePath := filepath.Join(tmpDirOptions.WorkingDir, DEFAULT_PATH_TO_REMOTE_STATE_FILE)
+
+	if err := os.MkdirAll(tmpDirOptions.WorkingDir, 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := shell.RunShellCommandWithOptions(tmpDirOptions, terragruntOptions.TerraformPath, remoteState.toTerraformRemoteConfigArgs()...); err != nil {
+		return err
+	}
+
+	destinationStateData, destinationState, err := readStateFile(destinationStatePath)
+	if err != nil {
+		return err
+	}
+
+	if !destinationState.IsEmpty() && destinationState.Lineage != sourceState.Lineage {
+		return errors.WithStackTrace(DestinationStateNotEmpty{Backend: remoteState.Backend, SourceLineage: sourceState.Lineage, DestinationLineage: destinationState.Lineage})
+	}
+
+	// The lineages were checked above, and an empty destination state may have any lineage
+	migratedStateData, err := prepareStateForRestore(sourceStateData, destinationStateData, true)
+	if err != nil {
+		return err
+	}
+
+	migratedState, err := parseTerraformState(migratedStateData)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		terragruntOptions.Logger.Printf("Dry run: would push state with serial %d and lineage %s to backend %s, verify it, and then switch this folder to backend %s", migratedState.Serial, migratedState.Lineage, remoteState.Backend, remoteState.Backend)
+		return nil
+	}
+
+	if err := ioutil.WriteFile(destinationStatePath, migratedStateData, 0644); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := shell.RunShellCommandWithOptions(tmpDirOptions, terragruntOptions.TerraformPath, "remote", "push"); err != nil {
+		return err
+	}
+
+	if err := shell.RunShellCommandWithOptions(tmpDirOptions, terragruntOptions.TerraformPath, "remote", "pull"); err != nil {
+		return err
+	}
+
+	if err := verifyMigratedStateFile(destinationStatePath, migratedState); err != nil {
+		return err
+	}
+
+	preMigrationStatePath := statePaths.RemoteStateFile + PRE_MIGRATION_STATE_FILE_SUFFIX
+	terragruntOptions.Logger.Printf("State was migrated successfully. Saving the latest state from the old backend to %s and switching to backend %s.", preMigrationStatePath, remoteState.Backend)
+	if err := copyStateFile(sourceStatePath, preMigrationStatePath); err != nil {
+		return err
+	}
+	if err := os.Remove(statePaths.RemoteStateFile); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, remoteState.toTerraformRemoteConfigArgs()...); err != nil {
+		return err
+	}
+
+	return verifyMigratedStateFile(statePaths.RemoteStateFile, migratedState)
+}
+
+// Return a copy of the given options that runs Terraform in the given temp folder of a migration. TF_DATA_DIR is unset,
+// so that Terraform keeps its remote state settings in the .terraform folder of the temp folder rather than in the data
+// dir of the current folder.
+func migrationDirOptions(dir string, terragruntOptions *options.TerragruntOptions) *options.TerragruntOptions {
+	dirOptions := terragruntOptions.Clone()
+	dirOptions.WorkingDir = dir
+	delete(dirOptions.Env, TF_DATA_DIR_ENV_VAR)
+	return dirOptions
+}
+
+// Copy the state file at the given source path to the given destination path, creating its folder if necessary
+func copyStateFile(sourcePath string, destinationPath string) error {
+	data, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destinationPath), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := ioutil.WriteFile(destinationPath, data, 0600); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return nil
+}
+
+// Return true if the given remote state settings from a Terraform state file have the same backend type and backend
+// configs as the given remote state settings from the Terragrunt config
+func remoteStateMatches(existingRemoteState *TerraformStateRemote, remoteStateFromTerragruntConfig RemoteState) bool {
+	return backendSettingsMatch(existingRemoteState.Type, existingRemoteState.Config, remoteStateFromTerragruntConfig)
+}
+
+// Verify the state file at the given path has the same serial and lineage as the given expected state
+func verifyMigratedStateFile(path string, expectedState *TerraformState) error {
+	_, actualState, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+
+	if actualState.Serial != expectedState.Serial || actualState.Lineage != expectedState.Lineage {
+		return errors.WithStackTrace(MigratedStateMismatch{
+			Path: path,
+			ExpectedSerial: expectedState.Serial,
+			ActualSerial: actualState.Serial,
+			ExpectedLineage: expectedState.Lineage,
+			ActualLineage: actualState.Lineage,
+		})
+	}
+
+	return nil
+}
+
+var NoRemoteStateToMigrate = fmt.Errorf("Remote state is not configured yet, so there is no state to migrate. Run any Terraform command that uses state (e.g. terragrunt plan) and Terragrunt will configure remote state for you.")
+
+var UseTerraformInitToMigrateState = fmt.Errorf("With Terraform 0.9 and above, state is stored using backends, and 'terraform init' migrates state between backends itself. Update the remoteState settings in your .terragrunt file and run any Terraform command that uses state (e.g. terragrunt plan) to start the migration.")
+
+type UnexpectedStateAfterPull struct {
+	Path string
+}
+
+func (err UnexpectedStateAfterPull) Error() string {
+	return fmt.Sprintf("After pulling remote state, %s no longer contains the expected remote state settings", err.Path)
+}
+
+type DestinationStateNotEmpty struct {
+	Backend            string
+	SourceLineage      string
+	DestinationLineage string
+}
+
+func (err DestinationStateNotEmpty) Error() string {
+	return fmt.Sprintf("Backend %s already contains state with lineage %s, which is different from the lineage %s of the state being migrated. Refusing to overwrite it.", err.Backend, err.DestinationLineage, err.SourceLineage)
+}
+
+type MigratedStateMismatch struct {
+	Path            string
+	ExpectedSerial  int
+	ActualSerial    int
+	ExpectedLineage string
+	ActualLineage   string
+}
+
+func (err MigratedStateMismatch) Error() string {
+	return fmt.Sprintf("Expected migrated state in %s to have serial %d and lineage %s, but it has serial %d and lineage %s", err.Path, err.ExpectedSerial, err.ExpectedLineage, err.ActualSerial, err.ActualLineage)
+}
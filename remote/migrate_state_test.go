@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"reflect"
+)
+
+func TestRemoteStateMatches(t *testing.T) {
+	t.Parallel()
+
+	existingRemoteState := &TerraformStateRemote{
+		Type: "s3",
+		Config: map[string]interface{}{
+			"bucket": "my-bucket",
+			"encrypt": "true",
+		},
+	}
+
+	testCases := []struct {
+		remoteState RemoteState
+		expected    bool
+	}{
+		{RemoteState{Backend: "s3", BackendConfigs: map[string]string{"bucket": "my-bucket", "encrypt": "true"}}, true},
+		{RemoteState{Backend: "consul", BackendConfigs: map[string]string{"bucket": "my-bucket", "encrypt": "true"}}, false},
+		{RemoteState{Backend: "s3", BackendConfigs: map[string]string{"bucket": "other-bucket", "encrypt": "true"}}, false},
+		{RemoteState{Backend: "s3", BackendConfigs: map[string]string{"bucket": "my-bucket"}}, false},
+		{RemoteState{Backend: "s3", BackendConfigs: map[string]string{"bucket": "my-bucket", "key": "true"}}, false},
+		{RemoteState{Backend: "s3"}, false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, remoteStateMatches(existingRemoteState, testCase.remoteState), "For remote state %v", testCase.remoteState)
+	}
+}
+
+func TestTerraformStateIsEmpty(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, (&TerraformState{}).IsEmpty())
+	assert.True(t, (&TerraformState{Modules: []TerraformStateModule{{Path: []string{"root"}}}}).IsEmpty())
+	assert.False(t, (&TerraformState{Modules: []TerraformStateModule{{Path: []string{"root"}, Outputs: map[string]interface{}{"foo": "bar"}}}}).IsEmpty())
+	assert.False(t, (&TerraformState{Modules: []TerraformStateModule{{Path: []string{"root"}, Resources: map[string]interface{}{"aws_instance.foo": map[string]interface{}{}}}}}).IsEmpty())
+}
+
+func TestVerifyMigratedStateFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	statePath := writeFile(t, tmpDir, "terraform.tfstate", TEST_STATE_FILE)
+
+	assert.Nil(t, verifyMigratedStateFile(statePath, &TerraformState{Serial: 7, Lineage: "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b"}))
+
+	err := verifyMigratedStateFile(statePath, &TerraformState{Serial: 8, Lineage: "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b"})
+	_, isMismatch := errors.Unwrap(err).(MigratedStateMismatch)
+	assert.True(t, isMismatch, "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	err = verifyMigratedStateFile(statePath, &TerraformState{Serial: 7, Lineage: "other-lineage"})
+	_, isMismatch = errors.Unwrap(err).(MigratedStateMismatch)
+	assert.True(t, isMismatch, "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
@@ -5,12 +5,34 @@ import (
 	"github.com/gruntwork-io/terragrunt/shell"
 	"fmt"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 )
 
+// For Terraform 0.9 and above, Terragrunt declares the backend in this file, unless the Terraform templates already
+// declare one themselves
+const BACKEND_CONFIG_FILE_NAME = "terragrunt_backend.tf"
+
+const BACKEND_CONFIG_FILE_TEMPLATE = `# This file was generated by Terragrunt from the remoteState settings in .terragrunt. Do not edit it by hand.
+terraform {
+  backend "%s" {}
+}
+`
+
+var BACKEND_BLOCK_REGEX = regexp.MustCompile(`backend\s+"[^"]+"\s*\{`)
+
 // Configuration for Terraform remote state
 type RemoteState struct {
 	Backend        string
 	BackendConfigs map[string]string
+
+	// The keys in BackendConfigs, e.g. access_key, whose values are secrets that must be redacted from everything
+	// Terragrunt logs
+	SensitiveBackendConfigs []string
 }
 
 // Fill in any default configuration for remote state
@@ -24,22 +46,40 @@ func (remoteState *RemoteState) Validate() error {
 		return errors.WithStackTrace(RemoteBackendMissing)
 	}
 
+	for _, key := range remoteState.SensitiveBackendConfigs {
+		if _, ok := remoteState.BackendConfigs[key]; !ok {
+			return errors.WithStackTrace(SensitiveBackendConfigNotFound(key))
+		}
+	}
+
 	// TODO: for the S3 backend, check that encryption is enabled
 	// TODO: for the S3 backend, use the AWS API to verify the S3 bucket has versioning enabled
 
 	return nil
 }
 
-// Configure Terraform remote state
-func (remoteState RemoteState) ConfigureRemoteState() error {
-	shouldConfigure, err := shouldConfigureRemoteState(remoteState)
+// Configure Terraform remote state, using the given paths to find the existing state settings. For Terraform 0.9 and
+// above, this is done by declaring a backend and running "terraform init". For older versions, this is done by running
+// "terraform remote config", and the given options are used to prompt the user if that would overwrite the existing
+// settings. Terraform runs with the environment, input, and output of the given options.
+func (remoteState RemoteState) ConfigureRemoteState(statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	terraformVersion, err := GetTerraformVersion(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	if usesBackends(terraformVersion) {
+		return remoteState.configureBackend(statePaths, terragruntOptions)
+	}
+
+	shouldConfigure, err := shouldConfigureRemoteState(remoteState, statePaths, terragruntOptions)
 	if err != nil {
 		return err
 	}
 
 	if shouldConfigure {
-		util.Logger.Printf("Configuring remote state for the %s backend", remoteState.Backend)
-		return shell.RunShellCommand("terraform", remoteState.toTerraformRemoteConfigArgs()...)
+		terragruntOptions.Logger.Printf("Configuring remote state for the %s backend", remoteState.Backend)
+		return shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, remoteState.toTerraformRemoteConfigArgs()...)
 	}
 
 	return nil
@@ -49,14 +89,14 @@ func (remoteState RemoteState) ConfigureRemoteState() error {
 //
 // 1. Remote state has not already been configured
 // 2. Remote state has been configured, but for a different backend type, and the user confirms it's OK to overwrite it.
-func shouldConfigureRemoteState(remoteStateFromTerragruntConfig RemoteState) (bool, error) {
-	state, err := ParseTerraformStateFileFromDefaultLocations()
+func shouldConfigureRemoteState(remoteStateFromTerragruntConfig RemoteState, statePaths StatePaths, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	state, err := statePaths.ParseStateFile()
 	if err != nil {
 		return false, err
 	}
 
 	if state != nil && state.IsRemote() {
-		return shouldOverrideExistingRemoteState(state.Remote, remoteStateFromTerragruntConfig)
+		return shouldOverrideExistingRemoteState(state.Remote, remoteStateFromTerragruntConfig, terragruntOptions)
 	} else {
 		return true, nil
 	}
@@ -65,15 +105,112 @@ func shouldConfigureRemoteState(remoteStateFromTerragruntConfig RemoteState) (bo
 // Check if the remote state that is already configured matches the one specified in the Terragrunt config. If it does,
 // return false to indicate remote state does not need to be configured again. If it doesn't, prompt the user whether
 // we should override the existing remote state setting.
-func shouldOverrideExistingRemoteState(existingRemoteState *TerraformStateRemote, remoteStateFromTerragruntConfig RemoteState) (bool, error) {
+func shouldOverrideExistingRemoteState(existingRemoteState *TerraformStateRemote, remoteStateFromTerragruntConfig RemoteState, terragruntOptions *options.TerragruntOptions) (bool, error) {
 	if existingRemoteState.Type == remoteStateFromTerragruntConfig.Backend {
-		util.Logger.Printf("Remote state is already configured for backend %s", existingRemoteState.Type)
+		terragruntOptions.Logger.Printf("Remote state is already configured for backend %s", existingRemoteState.Type)
 		return false, nil
 	} else {
-		return shell.PromptUserForYesNo(fmt.Sprintf("WARNING: Terraform remote state is already configured, but for backend %s, whereas your Terragrunt configuration specifies %s. Overwrite?", existingRemoteState.Type, remoteStateFromTerragruntConfig.Backend))
+		return shell.PromptUserForYesNo(fmt.Sprintf("WARNING: Terraform remote state is already configured, but for backend %s, whereas your Terragrunt configuration specifies %s. Overwrite? (To copy your existing state to the new backend instead, answer no and run 'terragrunt migrate-state')", existingRemoteState.Type, remoteStateFromTerragruntConfig.Backend), shell.NO_SAFE_ANSWER, terragruntOptions)
 	}
 }
 
+// Configure remote state for Terraform 0.9 and above by declaring a backend block and running "terraform init" with
+// the backend configs from the Terragrunt config. Terraform itself prompts the user if existing state needs to be
+// copied to a new backend.
+func (remoteState RemoteState) configureBackend(statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	state, err := statePaths.ParseStateFile()
+	if err != nil {
+		return err
+	}
+
+	if state != nil && state.IsBackend() && backendSettingsMatch(state.Backend.Type, state.Backend.Config, remoteState) {
+		terragruntOptions.Logger.Printf("Backend %s is already configured", state.Backend.Type)
+		return nil
+	}
+
+	if err := writeBackendConfigFile(remoteState.Backend, statePaths.WorkingDir, terragruntOptions); err != nil {
+		return err
+	}
+
+	terragruntOptions.Logger.Printf("Configuring the %s backend", remoteState.Backend)
+	return shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, remoteState.toTerraformInitArgs()...)
+}
+
+// Write a Terraform file that declares a backend of the given type to the given folder (or the current working
+// directory, if it's empty), unless the Terraform templates in that folder already declare a backend themselves
+func writeBackendConfigFile(backend string, dir string, terragruntOptions *options.TerragruntOptions) error {
+	templatesDeclareBackend, err := terraformTemplatesDeclareBackend(dir)
+	if err != nil {
+		return err
+	}
+
+	if templatesDeclareBackend {
+		terragruntOptions.Logger.Printf("Your Terraform templates already declare a backend, so Terragrunt will not generate %s", BACKEND_CONFIG_FILE_NAME)
+		return nil
+	}
+
+	contents := fmt.Sprintf(BACKEND_CONFIG_FILE_TEMPLATE, backend)
+	return errors.WithStackTrace(ioutil.WriteFile(filepath.Join(dir, BACKEND_CONFIG_FILE_NAME), []byte(contents), 0644))
+}
+
+// Return true if any of the Terraform templates in the given folder, other than the file generated by Terragrunt,
+// declare a backend
+func terraformTemplatesDeclareBackend(dir string) (bool, error) {
+	templates, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+
+	for _, template := range templates {
+		if filepath.Base(template) == BACKEND_CONFIG_FILE_NAME {
+			continue
+		}
+
+		bytes, err := ioutil.ReadFile(template)
+		if err != nil {
+			return false, errors.WithStackTrace(err)
+		}
+
+		if BACKEND_BLOCK_REGEX.Match(bytes) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Return true if the given backend type and configs, as recorded in a Terraform state file, are the same as the
+// backend and backend configs in the given remote state settings from the Terragrunt config
+func backendSettingsMatch(existingType string, existingConfig map[string]interface{}, remoteStateFromTerragruntConfig RemoteState) bool {
+	if existingType != remoteStateFromTerragruntConfig.Backend {
+		return false
+	}
+
+	if len(existingConfig) != len(remoteStateFromTerragruntConfig.BackendConfigs) {
+		return false
+	}
+
+	for key, value := range remoteStateFromTerragruntConfig.BackendConfigs {
+		existingValue, exists := existingConfig[key]
+		if !exists || fmt.Sprintf("%v", existingValue) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Convert the RemoteState config into the "terraform init" arguments used by Terraform 0.9 and above
+func (remoteState RemoteState) toTerraformInitArgs() []string {
+	args := []string{"init"}
+
+	for key, value := range remoteState.BackendConfigs {
+		args = append(args, fmt.Sprintf("-backend-config=%s=%s", key, value))
+	}
+
+	return args
+}
+
 // Convert the RemoteState config into the format used by Terraform
 func (remoteState RemoteState) toTerraformRemoteConfigArgs() []string {
 	baseArgs := []string{"remote", "config", "-backend", remoteState.Backend}
@@ -87,4 +224,43 @@ func (remoteState RemoteState) toTerraformRemoteConfigArgs() []string {
 	return append(baseArgs, backendConfigArgs...)
 }
 
-var RemoteBackendMissing = fmt.Errorf("The remoteState.backend field cannot be empty")
\ No newline at end of file
+// Register the values of the sensitive backend configs as secrets, so they're redacted from everything Terragrunt logs
+func (remoteState RemoteState) RegisterSecrets() {
+	for _, key := range remoteState.SensitiveBackendConfigs {
+		util.RegisterSensitiveKey(key)
+		util.RegisterSecret(remoteState.BackendConfigs[key])
+	}
+}
+
+// Return an id for the state these remote state settings point at, for use in lineage and serial checks when there is
+// no lock with a stateFileId: the backend followed by its configs, sorted by key, without the values of sensitive
+// configs, e.g. "s3 bucket=my-bucket key=app/terraform.tfstate region=us-east-1"
+func (remoteState RemoteState) StateId() string {
+	sensitive := map[string]bool{}
+	for _, key := range remoteState.SensitiveBackendConfigs {
+		sensitive[key] = true
+	}
+
+	keys := []string{}
+	for key := range remoteState.BackendConfigs {
+		if !sensitive[key] && !util.SENSITIVE_KEY_REGEX.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := []string{remoteState.Backend}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, remoteState.BackendConfigs[key]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+var RemoteBackendMissing = fmt.Errorf("The remoteState.backend field cannot be empty")
+
+type SensitiveBackendConfigNotFound string
+
+func (key SensitiveBackendConfigNotFound) Error() string {
+	return fmt.Sprintf("The remoteState.sensitiveBackendConfigs field lists %s, which is not one of the remoteState.backendConfigs", string(key))
+}
@@ -4,6 +4,10 @@ import (
 	"testing"
 	"github.com/stretchr/testify/assert"
 	"strings"
+	"os"
+	"fmt"
+	"reflect"
+	"github.com/gruntwork-io/terragrunt/errors"
 )
 
 func TestToTerraformRemoteConfigArgs(t *testing.T) {
@@ -32,6 +36,52 @@ func TestToTerraformRemoteConfigArgsNoBackendConfigs(t *testing.T) {
 	assertRemoteConfigArgsEqual(t, args, "remote config -backend s3")
 }
 
+func TestToTerraformInitArgs(t *testing.T) {
+	t.Parallel()
+
+	remoteState := RemoteState{
+		Backend: "s3",
+		BackendConfigs: map[string]string {
+			"encrypt": "true",
+			"bucket": "my-bucket",
+			"key": "terraform.tfstate",
+			"region": "us-east-1",
+		},
+	}
+	args := remoteState.toTerraformInitArgs()
+
+	assertRemoteConfigArgsEqual(t, args, "init -backend-config=encrypt=true -backend-config=bucket=my-bucket -backend-config=key=terraform.tfstate -backend-config=region=us-east-1")
+}
+
+func TestToTerraformInitArgsNoBackendConfigs(t *testing.T) {
+	t.Parallel()
+
+	remoteState := RemoteState{Backend: "s3"}
+	args := remoteState.toTerraformInitArgs()
+
+	assertRemoteConfigArgsEqual(t, args, "init")
+}
+
+func TestTerraformTemplatesDeclareBackend(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, tmpDir, "main.tf", `resource "aws_instance" "example" {}`)
+	writeFile(t, tmpDir, BACKEND_CONFIG_FILE_NAME, fmt.Sprintf(BACKEND_CONFIG_FILE_TEMPLATE, "s3"))
+
+	declaresBackend, err := terraformTemplatesDeclareBackend(tmpDir)
+	assert.Nil(t, err)
+	assert.False(t, declaresBackend, "The backend file generated by Terragrunt should be ignored")
+
+	writeFile(t, tmpDir, "backend.tf", "terraform {\n  backend \"consul\" {\n  }\n}\n")
+
+	declaresBackend, err = terraformTemplatesDeclareBackend(tmpDir)
+	assert.Nil(t, err)
+	assert.True(t, declaresBackend)
+}
+
 func assertRemoteConfigArgsEqual(t *testing.T, actualArgs []string, expectedArgs string) {
 	expected := strings.Split(expectedArgs, " ")
 	assert.Len(t, actualArgs, len(expected))
@@ -39,4 +89,32 @@ func assertRemoteConfigArgsEqual(t *testing.T, actualArgs []string, expectedArgs
 	for _, expectedArg := range expected {
 		assert.Contains(t, actualArgs, expectedArg)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateSensitiveBackendConfigs(t *testing.T) {
+	t.Parallel()
+
+	remoteState := RemoteState{
+		Backend: "s3",
+		BackendConfigs: map[string]string{"bucket": "my-bucket", "access_key": "AKIAEXAMPLE"},
+		SensitiveBackendConfigs: []string{"access_key"},
+	}
+	assert.Nil(t, remoteState.Validate())
+
+	remoteState.SensitiveBackendConfigs = []string{"access_key", "secret_key"}
+	err := remoteState.Validate()
+	assert.True(t, errors.IsError(err, SensitiveBackendConfigNotFound("secret_key")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestRemoteStateStateId(t *testing.T) {
+	t.Parallel()
+
+	remoteState := RemoteState{
+		Backend: "s3",
+		BackendConfigs: map[string]string{"region": "us-east-1", "bucket": "my-bucket", "key": "app/terraform.tfstate", "secret_key": "abc", "db_auth": "def"},
+		SensitiveBackendConfigs: []string{"db_auth"},
+	}
+
+	assert.Equal(t, "s3 bucket=my-bucket key=app/terraform.tfstate region=us-east-1", remoteState.StateId())
+	assert.Equal(t, "consul", RemoteState{Backend: "consul"}.StateId())
+}
@@ -0,0 +1,453 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+const DEFAULT_STATE_BACKUP_DIR = ".terragrunt-backups"
+const DEFAULT_MAX_STATE_BACKUPS = 10
+
+// Set maxBackups to this value to keep every state backup. A maxBackups of 0 means DEFAULT_MAX_STATE_BACKUPS.
+const UNLIMITED_STATE_BACKUPS = -1
+
+// The timestamp format used in the names of state backup files. It sorts lexicographically in chronological order.
+const STATE_BACKUP_TIMESTAMP_FORMAT = "20060102T150405.000000000Z"
+
+// Used when a state file has no lineage (e.g. it was created by an older version of Terraform)
+const UNKNOWN_LINEAGE = "unknown-lineage"
+
+// State backup files are named terraform-<timestamp>-serial-<serial>-<lineage>.tfstate
+var STATE_BACKUP_FILE_NAME_REGEX = regexp.MustCompile(`^terraform-(\d{8}T\d{6}\.\d{9}Z)-serial-(\d+)-(.+)\.tfstate$`)
+
+// Configuration for backing up Terraform state before it is modified
+type StateBackup struct {
+	BackupDir  string
+	MaxBackups int
+}
+
+// A single backup of a Terraform state file
+type StateBackupFile struct {
+	Path      string
+	Timestamp time.Time
+	Serial    int
+	Lineage   string
+}
+
+// Fill in any default configuration for state backups
+func (stateBackup *StateBackup) FillDefaults() {
+	if stateBackup.BackupDir == "" {
+		stateBackup.BackupDir = DEFAULT_STATE_BACKUP_DIR
+	}
+
+	if stateBackup.MaxBackups == 0 {
+		stateBackup.MaxBackups = DEFAULT_MAX_STATE_BACKUPS
+	}
+}
+
+// Validate that state backups are configured correctly
+func (stateBackup *StateBackup) Validate() error {
+	if stateBackup.MaxBackups < UNLIMITED_STATE_BACKUPS {
+		return errors.WithStackTrace(InvalidMaxStateBackups(stateBackup.MaxBackups))
+	}
+
+	return nil
+}
+
+// Take a backup of the current Terraform state at the given paths, if there is any, and delete old backups so that at
+// most MaxBackups are kept. If remote state is enabled, the latest state is pulled down first, using the given options,
+// so the backup is not out of date.
+func (stateBackup StateBackup) BackupCurrentState(statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	stateData, err := statePaths.pullCurrentState(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	return stateBackup.backupPulledState(stateData, statePaths, terragruntOptions)
+}
+
+// Take a backup of the given state data, which was pulled from the state at the given paths, unless it is nil, and
+// delete old backups so that at most MaxBackups are kept
+func (stateBackup StateBackup) backupPulledState(stateData []byte, statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	if stateData == nil {
+		terragruntOptions.Logger.Printf("No Terraform state found, so there is nothing to back up")
+		return nil
+	}
+
+	backupDir := stateBackup.backupDirForWorkspace(statePaths)
+
+	backup, err := backupStateData(stateData, backupDir, time.Now())
+	if err != nil {
+		return err
+	}
+	terragruntOptions.Logger.Printf("Backed up Terraform state to %s", backup.Path)
+
+	return pruneStateBackups(backupDir, stateBackup.MaxBackups, terragruntOptions)
+}
+
+// Return the folder in which to store backups of the state at the given paths. A relative backup dir is relative to
+// the working directory. Each workspace has its own state, so the backups for workspaces other than the default one
+// are stored in a subfolder named after the workspace.
+func (stateBackup StateBackup) backupDirForWorkspace(statePaths StatePaths) string {
+	backupDir := joinWithWorkingDir(statePaths.WorkingDir, stateBackup.BackupDir)
+
+	if statePaths.IsDefaultWorkspace() {
+		return backupDir
+	}
+
+	return filepath.Join(backupDir, statePaths.Workspace)
+}
+
+// Restore the state backup with the given file name in the backup dir, or the most recent backup if the name is empty.
+// Unless force is set, the backup must have the same lineage as the current state, so that the state of one set of
+// templates is never replaced by that of another. The current state is backed up first. The serial of the restored
+// state is bumped above the current serial so that Terraform treats it as the newest version, and if remote state is
+// enabled, the restored state is pushed to the remote backend. Terraform runs with the given options.
+func (stateBackup StateBackup) RestoreBackup(backupName string, force bool, statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	backup, err := findStateBackup(stateBackup.backupDirForWorkspace(statePaths), backupName)
+	if err != nil {
+		return err
+	}
+
+	backupData, err := ioutil.ReadFile(backup.Path)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	currentStateData, err := statePaths.pullCurrentState(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	restoredStateData, err := prepareStateForRestore(backupData, currentStateData, force)
+	if err != nil {
+		return err
+	}
+
+	if err := stateBackup.backupPulledState(currentStateData, statePaths, terragruntOptions); err != nil {
+		return err
+	}
+
+	statePath := statePaths.FindStateFile()
+	usesBackend := false
+
+	if statePath == "" {
+		statePath = statePaths.LocalStateFile
+	} else {
+		state, err := ParseTerraformStateFile(statePath)
+		if err != nil {
+			return err
+		}
+		usesBackend = state.IsBackend()
+	}
+
+	if usesBackend {
+		return restoreStateToBackend(backup.Path, restoredStateData, terragruntOptions)
+	}
+
+	terragruntOptions.Logger.Printf("Restoring Terraform state from %s to %s", backup.Path, statePath)
+	if err := ioutil.WriteFile(statePath, restoredStateData, 0644); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if statePath == statePaths.RemoteStateFile {
+		return shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, "remote", "push")
+	}
+
+	return nil
+}
+
+// Push the given restored state data to the backend configured for Terraform 0.9 and above in the working directory
+// of the given options using "terraform state push"
+func restoreStateToBackend(backupPath string, restoredStateData []byte, terragruntOptions *options.TerragruntOptions) error {
+	tmpFile, err := ioutil.TempFile("", "terragrunt-restored-state")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(restoredStateData); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	terragruntOptions.Logger.Printf("Restoring Terraform state from %s to the configured backend", backupPath)
+	return shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, "state", "push", tmpFile.Name())
+}
+
+// Return the current Terraform state data, or nil if there is no state. If remote state is enabled, first pull the
+// latest state from the remote backend, using the given options, as the copy in the .terraform folder may be out of date.
+func (statePaths StatePaths) pullCurrentState(terragruntOptions *options.TerragruntOptions) ([]byte, error) {
+	statePath := statePaths.FindStateFile()
+	if statePath == "" {
+		return nil, nil
+	}
+
+	state, err := ParseTerraformStateFile(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.IsRemote() {
+		if err := shell.RunShellCommandWithOptions(terragruntOptions, terragruntOptions.TerraformPath, "remote", "pull"); err != nil {
+			return nil, err
+		}
+	}
+
+	stateData, _, err := statePaths.readCurrentState(terragruntOptions)
+	return stateData, err
+}
+
+// Write the given state data into the given backup dir, using a file name that contains the given timestamp and the
+// serial and lineage of the state
+func backupStateData(stateData []byte, backupDir string, timestamp time.Time) (*StateBackupFile, error) {
+	state, err := parseTerraformState(stateData)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := state.Lineage
+	if lineage == "" {
+		lineage = UNKNOWN_LINEAGE
+	}
+
+	// State files may contain secrets, so make sure only the current user can read the backups
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	backup := &StateBackupFile{
+		Path: filepath.Join(backupDir, stateBackupFileName(timestamp, state.Serial, lineage)),
+		Timestamp: timestamp.UTC(),
+		Serial: state.Serial,
+		Lineage: lineage,
+	}
+
+	if err := ioutil.WriteFile(backup.Path, stateData, 0600); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return backup, nil
+}
+
+// Return the file name to use for a state backup with the given timestamp, serial, and lineage
+func stateBackupFileName(timestamp time.Time, serial int, lineage string) string {
+	return fmt.Sprintf("terraform-%s-serial-%d-%s.tfstate", timestamp.UTC().Format(STATE_BACKUP_TIMESTAMP_FORMAT), serial, lineage)
+}
+
+// Parse the timestamp, serial, and lineage out of the name of a state backup file. Returns nil if the given path does
+// not look like a state backup file.
+func parseStateBackupFileName(path string) *StateBackupFile {
+	matches := STATE_BACKUP_FILE_NAME_REGEX.FindStringSubmatch(filepath.Base(path))
+	if matches == nil {
+		return nil
+	}
+
+	timestamp, err := time.Parse(STATE_BACKUP_TIMESTAMP_FORMAT, matches[1])
+	if err != nil {
+		return nil
+	}
+
+	serial, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return nil
+	}
+
+	return &StateBackupFile{Path: path, Timestamp: timestamp, Serial: serial, Lineage: matches[3]}
+}
+
+// Return all the state backups in the given dir, sorted from oldest to newest. If the dir doesn't exist, return an
+// empty list.
+func ListStateBackups(backupDir string) ([]StateBackupFile, error) {
+	backups := []StateBackupFile{}
+
+	if !util.FileExists(backupDir) {
+		return backups, nil
+	}
+
+	files, err := ioutil.ReadDir(backupDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		backup := parseStateBackupFileName(filepath.Join(backupDir, file.Name()))
+		if backup != nil {
+			backups = append(backups, *backup)
+		}
+	}
+
+	sort.Sort(stateBackupsByTimestamp(backups))
+	return backups, nil
+}
+
+// Find the state backup with the given file name in the given backup dir. If the name is empty, return the most
+// recent backup.
+func findStateBackup(backupDir string, backupName string) (*StateBackupFile, error) {
+	backups, err := ListStateBackups(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backups) == 0 {
+		return nil, errors.WithStackTrace(NoStateBackupsFound(backupDir))
+	}
+
+	if backupName == "" {
+		return &backups[len(backups) - 1], nil
+	}
+
+	for _, backup := range backups {
+		if filepath.Base(backup.Path) == filepath.Base(backupName) {
+			return &backup, nil
+		}
+	}
+
+	return nil, errors.WithStackTrace(StateBackupNotFound{BackupDir: backupDir, BackupName: backupName})
+}
+
+// Delete the oldest state backups in the given dir so that at most maxBackups remain, unless maxBackups is
+// UNLIMITED_STATE_BACKUPS
+func pruneStateBackups(backupDir string, maxBackups int, terragruntOptions *options.TerragruntOptions) error {
+	if maxBackups == UNLIMITED_STATE_BACKUPS {
+		return nil
+	}
+
+	backups, err := ListStateBackups(backupDir)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(backups) - maxBackups; i++ {
+		terragruntOptions.Logger.Printf("Deleting old state backup %s", backups[i].Path)
+		if err := os.Remove(backups[i].Path); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	return nil
+}
+
+// Return a copy of the given backup state data that is ready to be written over the given current state data (which
+// may be empty if there is no current state). The serial is set higher than both the backup and current serial so
+// Terraform sees the restored state as the newest version, and the remote section is taken from the current state, as
+// that is where the state must be stored now. Unless force is set, return an error if the two states have different
+// lineages.
+func prepareStateForRestore(backupData []byte, currentStateData []byte, force bool) ([]byte, error) {
+	backupState, err := parseTerraformState(backupData)
+	if err != nil {
+		return nil, err
+	}
+
+	restoredState, err := parseRawTerraformState(backupData)
+	if err != nil {
+		return nil, err
+	}
+
+	serial := backupState.Serial
+	delete(restoredState, "remote")
+
+	if len(currentStateData) > 0 {
+		currentState, err := parseTerraformState(currentStateData)
+		if err != nil {
+			return nil, err
+		}
+
+		rawCurrentState, err := parseRawTerraformState(currentStateData)
+		if err != nil {
+			return nil, err
+		}
+
+		if !force && currentState.Lineage != "" && backupState.Lineage != "" && currentState.Lineage != backupState.Lineage {
+			return nil, errors.WithStackTrace(BackupLineageMismatch{BackupLineage: backupState.Lineage, CurrentLineage: currentState.Lineage})
+		}
+
+		if currentState.Serial > serial {
+			serial = currentState.Serial
+		}
+
+		if currentState.IsRemote() {
+			restoredState["remote"] = rawCurrentState["remote"]
+		}
+	}
+
+	restoredState["serial"] = serial + 1
+
+	out, err := json.MarshalIndent(restoredState, "", "    ")
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return out, nil
+}
+
+// Parse the Terraform state file data in the given byte slice into a generic map, preserving all fields and number
+// formats, so it can be modified and written back out without losing any data
+func parseRawTerraformState(terraformStateData []byte) (map[string]interface{}, error) {
+	rawState := map[string]interface{}{}
+
+	decoder := json.NewDecoder(bytes.NewReader(terraformStateData))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&rawState); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return rawState, nil
+}
+
+type stateBackupsByTimestamp []StateBackupFile
+
+func (backups stateBackupsByTimestamp) Len() int           { return len(backups) }
+func (backups stateBackupsByTimestamp) Swap(i, j int)      { backups[i], backups[j] = backups[j], backups[i] }
+func (backups stateBackupsByTimestamp) Less(i, j int) bool { return backups[i].Timestamp.Before(backups[j].Timestamp) }
+
+type InvalidMaxStateBackups int
+
+func (maxBackups InvalidMaxStateBackups) Error() string {
+	return fmt.Sprintf("The stateBackup.maxBackups field must be %d (keep every backup), 0 (keep the default of %d), or positive, but got %d", UNLIMITED_STATE_BACKUPS, DEFAULT_MAX_STATE_BACKUPS, int(maxBackups))
+}
+
+type NoStateBackupsFound string
+
+func (backupDir NoStateBackupsFound) Error() string {
+	return fmt.Sprintf("Could not find any state backups in %s", string(backupDir))
+}
+
+type StateBackupNotFound struct {
+	BackupDir  string
+	BackupName string
+}
+
+func (err StateBackupNotFound) Error() string {
+	return fmt.Sprintf("Could not find state backup %s in %s", err.BackupName, err.BackupDir)
+}
+
+type BackupLineageMismatch struct {
+	BackupLineage  string
+	CurrentLineage string
+}
+
+func (err BackupLineageMismatch) Error() string {
+	return fmt.Sprintf("The state backup has lineage %s, but the current state has lineage %s, so the backup is of a different state and restoring it would replace that state. If you are sure, run state-restore again with -force.", err.BackupLineage, err.CurrentLineage)
+}
@@ -0,0 +1,269 @@
+package remote
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"reflect"
+	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+const TEST_STATE_FILE =
+`
+{
+	"version": 3,
+	"serial": 7,
+	"lineage": "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b",
+	"modules": []
+}
+`
+
+func TestBackupStateData(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	backupDir := filepath.Join(tmpDir, "backups")
+	timestamp := time.Date(2016, time.August, 5, 10, 4, 10, 123, time.UTC)
+
+	backup, err := backupStateData([]byte(TEST_STATE_FILE), backupDir, timestamp)
+	assert.Nil(t, err)
+
+	assert.Equal(t, filepath.Join(backupDir, "terraform-20160805T100410.000000123Z-serial-7-8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b.tfstate"), backup.Path)
+	assert.Equal(t, 7, backup.Serial)
+	assert.Equal(t, "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b", backup.Lineage)
+	assert.Equal(t, timestamp, backup.Timestamp)
+
+	contents, err := ioutil.ReadFile(backup.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, TEST_STATE_FILE, string(contents))
+}
+
+func TestBackupStateDataNoLineage(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	backupDir := filepath.Join(tmpDir, "backups")
+
+	backup, err := backupStateData([]byte(`{"version": 1, "serial": 3}`), backupDir, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, UNKNOWN_LINEAGE, backup.Lineage)
+	assert.Equal(t, *backup, *parseStateBackupFileName(backup.Path))
+}
+
+func TestParseStateBackupFileNameInvalid(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, parseStateBackupFileName("terraform.tfstate"))
+	assert.Nil(t, parseStateBackupFileName("terraform-not-a-timestamp-serial-1-abc.tfstate"))
+	assert.Nil(t, parseStateBackupFileName("terraform-20160805T100410.000000123Z-serial-abc-abc.tfstate"))
+}
+
+func TestListStateBackupsMissingDir(t *testing.T) {
+	t.Parallel()
+
+	backups, err := ListStateBackups("/this/dir/does/not/exist")
+	assert.Nil(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestListAndPruneStateBackups(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	backupDir := filepath.Join(tmpDir, "backups")
+	writeFile(t, backupDir, "not-a-backup.txt", "")
+
+	start := time.Date(2016, time.August, 5, 10, 4, 10, 0, time.UTC)
+	for _, minutes := range []int{3, 1, 4, 0, 2} {
+		_, err := backupStateData([]byte(TEST_STATE_FILE), backupDir, start.Add(time.Duration(minutes) * time.Minute))
+		assert.Nil(t, err)
+	}
+
+	backups, err := ListStateBackups(backupDir)
+	assert.Nil(t, err)
+	assertBackupTimestamps(t, backups, start, 0, 1, 2, 3, 4)
+
+	assert.Nil(t, pruneStateBackups(backupDir, UNLIMITED_STATE_BACKUPS, options.NewTerragruntOptionsForTest("state_backup_test")))
+
+	backups, err = ListStateBackups(backupDir)
+	assert.Nil(t, err)
+	assertBackupTimestamps(t, backups, start, 0, 1, 2, 3, 4)
+
+	assert.Nil(t, pruneStateBackups(backupDir, 2, options.NewTerragruntOptionsForTest("state_backup_test")))
+
+	backups, err = ListStateBackups(backupDir)
+	assert.Nil(t, err)
+	assertBackupTimestamps(t, backups, start, 3, 4)
+	assert.True(t, util.FileExists(filepath.Join(backupDir, "not-a-backup.txt")), "Prune should not delete files that are not backups")
+}
+
+func TestFindStateBackup(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	backupDir := filepath.Join(tmpDir, "backups")
+
+	_, err := findStateBackup(backupDir, "")
+	assert.True(t, errors.IsError(err, NoStateBackupsFound(backupDir)), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	older, err := backupStateData([]byte(TEST_STATE_FILE), backupDir, time.Now().Add(-1 * time.Hour))
+	assert.Nil(t, err)
+	newer, err := backupStateData([]byte(TEST_STATE_FILE), backupDir, time.Now())
+	assert.Nil(t, err)
+
+	latest, err := findStateBackup(backupDir, "")
+	assert.Nil(t, err)
+	assert.Equal(t, newer.Path, latest.Path)
+
+	named, err := findStateBackup(backupDir, filepath.Base(older.Path))
+	assert.Nil(t, err)
+	assert.Equal(t, older.Path, named.Path)
+
+	_, err = findStateBackup(backupDir, "no-such-backup.tfstate")
+	assert.True(t, errors.IsError(err, StateBackupNotFound{BackupDir: backupDir, BackupName: "no-such-backup.tfstate"}), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestPrepareStateForRestoreNoCurrentState(t *testing.T) {
+	t.Parallel()
+
+	restored, err := prepareStateForRestore([]byte(TEST_STATE_FILE), nil, false)
+	assert.Nil(t, err)
+
+	state, err := parseTerraformState(restored)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, state.Serial)
+	assert.Equal(t, "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b", state.Lineage)
+	assert.False(t, state.IsRemote())
+}
+
+func TestPrepareStateForRestoreRemoteCurrentState(t *testing.T) {
+	t.Parallel()
+
+	currentState :=
+	`
+	{
+		"version": 3,
+		"serial": 12,
+		"lineage": "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b",
+		"remote": {
+			"type": "s3",
+			"config": {
+				"bucket": "new-bucket"
+			}
+		}
+	}
+	`
+
+	backupState :=
+	`
+	{
+		"version": 3,
+		"serial": 7,
+		"lineage": "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b",
+		"remote": {
+			"type": "s3",
+			"config": {
+				"bucket": "old-bucket"
+			}
+		},
+		"modules": [{"path": ["root"], "outputs": {"foo": "bar"}, "resources": {}}]
+	}
+	`
+
+	restored, err := prepareStateForRestore([]byte(backupState), []byte(currentState), false)
+	assert.Nil(t, err)
+
+	state, err := parseTerraformState(restored)
+	assert.Nil(t, err)
+	assert.Equal(t, 13, state.Serial)
+	assert.True(t, state.IsRemote())
+	assert.Equal(t, "new-bucket", state.Remote.Config["bucket"])
+	assert.Len(t, state.Modules, 1)
+	assert.Equal(t, "bar", state.Modules[0].Outputs["foo"])
+}
+
+func TestPrepareStateForRestoreLineageMismatch(t *testing.T) {
+	t.Parallel()
+
+	currentState :=
+	`
+	{
+		"version": 3,
+		"serial": 12,
+		"lineage": "0d0c4ab5-2b8c-4cb7-9b0e-6a3c1c7a2f11"
+	}
+	`
+
+	_, err := prepareStateForRestore([]byte(TEST_STATE_FILE), []byte(currentState), false)
+	expected := BackupLineageMismatch{BackupLineage: "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b", CurrentLineage: "0d0c4ab5-2b8c-4cb7-9b0e-6a3c1c7a2f11"}
+	assert.True(t, errors.IsError(err, expected), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	restored, err := prepareStateForRestore([]byte(TEST_STATE_FILE), []byte(currentState), true)
+	assert.Nil(t, err)
+
+	state, err := parseTerraformState(restored)
+	assert.Nil(t, err)
+	assert.Equal(t, 13, state.Serial)
+	assert.Equal(t, "8a2bb86a-1e5b-4d3e-8f0a-0f3c8f1a7e4b", state.Lineage)
+}
+
+func TestStateBackupFillDefaultsAndValidate(t *testing.T) {
+	t.Parallel()
+
+	stateBackup := &StateBackup{}
+	stateBackup.FillDefaults()
+	assert.Equal(t, DEFAULT_STATE_BACKUP_DIR, stateBackup.BackupDir)
+	assert.Equal(t, DEFAULT_MAX_STATE_BACKUPS, stateBackup.MaxBackups)
+	assert.Nil(t, stateBackup.Validate())
+
+	unlimited := &StateBackup{MaxBackups: UNLIMITED_STATE_BACKUPS}
+	unlimited.FillDefaults()
+	assert.Equal(t, UNLIMITED_STATE_BACKUPS, unlimited.MaxBackups)
+	assert.Nil(t, unlimited.Validate())
+
+	invalid := &StateBackup{MaxBackups: -2}
+	err := invalid.Validate()
+	assert.True(t, errors.IsError(err, InvalidMaxStateBackups(-2)), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func assertBackupTimestamps(t *testing.T, backups []StateBackupFile, start time.Time, expectedMinutes ... int) {
+	assert.Len(t, backups, len(expectedMinutes))
+	for i, minutes := range expectedMinutes {
+		if i < len(backups) {
+			assert.Equal(t, start.Add(time.Duration(minutes) * time.Minute), backups[i].Timestamp)
+		}
+	}
+}
+
+func createTempDir(t *testing.T) string {
+	tmpDir, err := ioutil.TempDir("", "terragrunt-remote-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
+func writeFile(t *testing.T, dir string, name string, contents string) string {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
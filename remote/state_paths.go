@@ -0,0 +1,165 @@
+package remote
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Terraform keeps modules, the remote state settings, and the cached copy of remote state in this folder, unless the
+// TF_DATA_DIR environment variable says otherwise
+const DEFAULT_DATA_DIR = ".terraform"
+const TF_DATA_DIR_ENV_VAR = "TF_DATA_DIR"
+
+// The name of the workspace (called an "environment" before Terraform 0.10) Terraform uses if you haven't selected one
+const DEFAULT_WORKSPACE = "default"
+
+// Terraform records the currently selected workspace in this file in the data dir, unless the TF_WORKSPACE
+// environment variable says otherwise
+const WORKSPACE_FILE_NAME = "environment"
+const TF_WORKSPACE_ENV_VAR = "TF_WORKSPACE"
+
+// When storing Terraform state locally, the state of every workspace other than the default one is kept in a
+// subfolder of this folder
+const WORKSPACE_STATE_DIR = "terraform.tfstate.d"
+
+// The paths to the state files used by a particular Terraform command
+type StatePaths struct {
+	// The folder in which Terraform runs, or an empty string for the current working directory
+	WorkingDir            string
+	// The path to the state file when storing state locally
+	LocalStateFile        string
+	// The path to the file where Terraform records the remote state settings and, before Terraform 0.9, caches remote
+	// state
+	RemoteStateFile       string
+	// The name of the currently selected workspace
+	Workspace             string
+	// True if the Terragrunt config enables remote state, in which case Terraform uses the remote state and ignores
+	// any local state file
+	RemoteStateConfigured bool
+}
+
+// Return the paths Terraform uses for state files when no options, environment variables, or workspaces change them
+func DefaultStatePaths() StatePaths {
+	return StatePaths{
+		LocalStateFile: DEFAULT_PATH_TO_LOCAL_STATE_FILE,
+		RemoteStateFile: DEFAULT_PATH_TO_REMOTE_STATE_FILE,
+		Workspace: DEFAULT_WORKSPACE,
+	}
+}
+
+// Return the paths Terraform will use for state files when run with the Terraform args, in the working directory, and
+// with the environment variables of the given options. This takes into account the -state option, the TF_DATA_DIR and
+// TF_WORKSPACE environment variables, and the currently selected workspace.
+func ResolveStatePaths(terragruntOptions *options.TerragruntOptions) StatePaths {
+	return resolveStatePaths(terragruntOptions.TerraformCliArgs, terragruntOptions.WorkingDir, terragruntOptions.Getenv)
+}
+
+// Return the paths Terraform will use for state files when run with the given arguments in the given working
+// directory, using the given function to look up environment variables
+func resolveStatePaths(terraformArgs []string, workingDir string, getEnv func(string) string) StatePaths {
+	dataDir := getEnv(TF_DATA_DIR_ENV_VAR)
+	if dataDir == "" {
+		dataDir = DEFAULT_DATA_DIR
+	}
+	dataDir = joinWithWorkingDir(workingDir, dataDir)
+
+	workspace := getEnv(TF_WORKSPACE_ENV_VAR)
+	if workspace == "" {
+		workspace = readSelectedWorkspace(dataDir)
+	}
+
+	localStateFile := DEFAULT_PATH_TO_LOCAL_STATE_FILE
+	if workspace != DEFAULT_WORKSPACE {
+		localStateFile = filepath.Join(WORKSPACE_STATE_DIR, workspace, DEFAULT_PATH_TO_LOCAL_STATE_FILE)
+	}
+
+	if stateArg := findStateArg(terraformArgs); stateArg != "" {
+		localStateFile = stateArg
+	}
+
+	return StatePaths{
+		WorkingDir: workingDir,
+		LocalStateFile: joinWithWorkingDir(workingDir, localStateFile),
+		RemoteStateFile: filepath.Join(dataDir, filepath.Base(DEFAULT_PATH_TO_REMOTE_STATE_FILE)),
+		Workspace: workspace,
+	}
+}
+
+// Return the given path joined with the given working directory, unless the path is absolute. Terraform resolves
+// relative paths, such as the value of the -state option, relative to the folder it runs in.
+func joinWithWorkingDir(workingDir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(workingDir, path)
+}
+
+// Return the name of the workspace selected in the given data dir, or the default workspace if none is selected
+func readSelectedWorkspace(dataDir string) string {
+	bytes, err := ioutil.ReadFile(filepath.Join(dataDir, WORKSPACE_FILE_NAME))
+	if err != nil {
+		return DEFAULT_WORKSPACE
+	}
+
+	workspace := strings.TrimSpace(string(bytes))
+	if workspace == "" {
+		return DEFAULT_WORKSPACE
+	}
+
+	return workspace
+}
+
+// Return the value of the -state option in the given Terraform arguments, which can be specified either as
+// -state=path or -state path, or an empty string if the option isn't specified
+func findStateArg(terraformArgs []string) string {
+	for i, arg := range terraformArgs {
+		trimmedArg := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if trimmedArg == arg {
+			continue
+		}
+
+		if strings.HasPrefix(trimmedArg, "state=") {
+			return strings.TrimPrefix(trimmedArg, "state=")
+		}
+
+		if trimmedArg == "state" && i + 1 < len(terraformArgs) {
+			return terraformArgs[i + 1]
+		}
+	}
+
+	return ""
+}
+
+// Return true if these paths are for the default workspace
+func (statePaths StatePaths) IsDefaultWorkspace() bool {
+	return statePaths.Workspace == DEFAULT_WORKSPACE
+}
+
+// Return the path to the Terraform state file. If remote state is configured and the remote state file exists, return
+// the remote state file, as any local state file is most likely left over from before remote state was enabled.
+// Otherwise, prefer the local state file over the remote state file. If neither exists, return an empty string.
+func (statePaths StatePaths) FindStateFile() string {
+	if statePaths.RemoteStateConfigured && util.FileExists(statePaths.RemoteStateFile) {
+		return statePaths.RemoteStateFile
+	} else if util.FileExists(statePaths.LocalStateFile) {
+		return statePaths.LocalStateFile
+	} else if util.FileExists(statePaths.RemoteStateFile) {
+		return statePaths.RemoteStateFile
+	} else {
+		return ""
+	}
+}
+
+// Parse the Terraform state file at these paths. If there is no state file, return nil.
+func (statePaths StatePaths) ParseStateFile() (*TerraformState, error) {
+	path := statePaths.FindStateFile()
+	if path == "" {
+		return nil, nil
+	}
+
+	return ParseTerraformStateFile(path)
+}
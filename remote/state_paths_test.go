@@ -0,0 +1,153 @@
+package remote
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/options"
+	"os"
+	"path/filepath"
+)
+
+func TestResolveStatePathsDefaults(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest("")
+	terragruntOptions.TerraformCliArgs = []string{"plan"}
+
+	statePaths := ResolveStatePaths(terragruntOptions)
+	assert.Equal(t, DefaultStatePaths(), statePaths)
+	assert.True(t, statePaths.IsDefaultWorkspace())
+}
+
+func TestResolveStatePathsStateArg(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"plan", "-state=foo.tfstate"}, "foo.tfstate"},
+		{[]string{"plan", "--state=foo.tfstate"}, "foo.tfstate"},
+		{[]string{"apply", "-state", "bar/baz.tfstate", "-input=false"}, "bar/baz.tfstate"},
+		{[]string{"apply", "-state-out=out.tfstate"}, DEFAULT_PATH_TO_LOCAL_STATE_FILE},
+		{[]string{"apply", "state"}, DEFAULT_PATH_TO_LOCAL_STATE_FILE},
+		{[]string{"apply", "-state"}, DEFAULT_PATH_TO_LOCAL_STATE_FILE},
+	}
+
+	for _, testCase := range testCases {
+		statePaths := resolveStatePaths(testCase.args, "", envFromMap(map[string]string{}))
+		assert.Equal(t, testCase.expected, statePaths.LocalStateFile, "For args %v", testCase.args)
+	}
+}
+
+func TestResolveStatePathsDataDirAndWorkspaceFromEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{TF_DATA_DIR_ENV_VAR: "/tmp/terraform-data", TF_WORKSPACE_ENV_VAR: "stage"}
+	statePaths := resolveStatePaths([]string{"plan"}, "", envFromMap(env))
+
+	assert.Equal(t, StatePaths{
+		LocalStateFile: filepath.Join(WORKSPACE_STATE_DIR, "stage", DEFAULT_PATH_TO_LOCAL_STATE_FILE),
+		RemoteStateFile: "/tmp/terraform-data/terraform.tfstate",
+		Workspace: "stage",
+	}, statePaths)
+	assert.False(t, statePaths.IsDefaultWorkspace())
+}
+
+func TestResolveStatePathsSelectedWorkspace(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, tmpDir, WORKSPACE_FILE_NAME, "prod\n")
+
+	statePaths := resolveStatePaths([]string{"plan", "-state=custom.tfstate"}, "", envFromMap(map[string]string{TF_DATA_DIR_ENV_VAR: tmpDir}))
+
+	assert.Equal(t, StatePaths{
+		LocalStateFile: "custom.tfstate",
+		RemoteStateFile: filepath.Join(tmpDir, "terraform.tfstate"),
+		Workspace: "prod",
+	}, statePaths)
+}
+
+func TestResolveStatePathsWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{TF_DATA_DIR_ENV_VAR: "data"}
+	statePaths := resolveStatePaths([]string{"plan", "-state=/tmp/absolute.tfstate"}, "infra/vpc", envFromMap(env))
+
+	assert.Equal(t, StatePaths{
+		WorkingDir: "infra/vpc",
+		LocalStateFile: "/tmp/absolute.tfstate",
+		RemoteStateFile: "infra/vpc/data/terraform.tfstate",
+		Workspace: DEFAULT_WORKSPACE,
+	}, statePaths)
+
+	statePaths = resolveStatePaths([]string{"plan"}, "infra/vpc", envFromMap(map[string]string{}))
+	assert.Equal(t, "infra/vpc/terraform.tfstate", statePaths.LocalStateFile)
+	assert.Equal(t, "infra/vpc/.terraform/terraform.tfstate", statePaths.RemoteStateFile)
+
+	stateBackup := StateBackup{BackupDir: DEFAULT_STATE_BACKUP_DIR}
+	assert.Equal(t, filepath.Join("infra/vpc", DEFAULT_STATE_BACKUP_DIR), stateBackup.backupDirForWorkspace(statePaths))
+}
+
+func TestFindStateFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	statePaths := StatePaths{
+		LocalStateFile: filepath.Join(tmpDir, "local.tfstate"),
+		RemoteStateFile: filepath.Join(tmpDir, "remote.tfstate"),
+		Workspace: DEFAULT_WORKSPACE,
+	}
+	assert.Equal(t, "", statePaths.FindStateFile())
+
+	writeFile(t, tmpDir, "remote.tfstate", TEST_STATE_FILE)
+	assert.Equal(t, statePaths.RemoteStateFile, statePaths.FindStateFile())
+
+	writeFile(t, tmpDir, "local.tfstate", TEST_STATE_FILE)
+	assert.Equal(t, statePaths.LocalStateFile, statePaths.FindStateFile())
+}
+
+func TestFindStateFileRemoteStateConfigured(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	statePaths := StatePaths{
+		LocalStateFile: filepath.Join(tmpDir, "local.tfstate"),
+		RemoteStateFile: filepath.Join(tmpDir, "remote.tfstate"),
+		Workspace: DEFAULT_WORKSPACE,
+		RemoteStateConfigured: true,
+	}
+	assert.Equal(t, "", statePaths.FindStateFile())
+
+	writeFile(t, tmpDir, "local.tfstate", TEST_STATE_FILE)
+	assert.Equal(t, statePaths.LocalStateFile, statePaths.FindStateFile(), "Before remote state is configured in Terraform, the local state is the only state")
+
+	writeFile(t, tmpDir, "remote.tfstate", TEST_STATE_FILE)
+	assert.Equal(t, statePaths.RemoteStateFile, statePaths.FindStateFile(), "A stale local state file must not hide the remote state")
+}
+
+func TestStatePinIdAndBackupDirUseWorkspace(t *testing.T) {
+	t.Parallel()
+
+	defaultPaths := DefaultStatePaths()
+	stagePaths := StatePaths{Workspace: "stage"}
+	stateBackup := StateBackup{BackupDir: DEFAULT_STATE_BACKUP_DIR}
+
+	assert.Equal(t, "my-app", statePinId("my-app", defaultPaths))
+	assert.Equal(t, "my-app/stage", statePinId("my-app", stagePaths))
+	assert.Equal(t, DEFAULT_STATE_BACKUP_DIR, stateBackup.backupDirForWorkspace(defaultPaths))
+	assert.Equal(t, filepath.Join(DEFAULT_STATE_BACKUP_DIR, "stage"), stateBackup.backupDirForWorkspace(stagePaths))
+}
+
+func envFromMap(env map[string]string) func(string) string {
+	return func(name string) string {
+		return env[name]
+	}
+}
@@ -0,0 +1,178 @@
+package remote
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// The folder in the Terragrunt home dir where we record the lineage and serial last seen for each state. Each state
+// gets its own file, so Terragrunt processes running at the same time, e.g. in an apply-all, don't overwrite each
+// other's pins.
+const STATE_PINS_DIR_NAME = "state-pins"
+
+// The lineage and serial Terragrunt last saw in the state with a given id. The lineage is a unique id that Terraform
+// assigns to a state file when it is first created, so if the lineage changes, it almost certainly means Terragrunt is
+// now pointing at the state for a totally different set of templates.
+type StatePin struct {
+	StateId string
+	Lineage string
+	Serial  int
+}
+
+// Check that the lineage of the current Terraform state matches the lineage previously recorded for the given state id,
+// and that its serial has not gone backwards. If this is the first time we see this state id, record its lineage and
+// serial so we can check them next time. The state id is the stateFileId of the lock, if there is one, or else
+// identifies the remote state settings (see RemoteState.StateId). Each workspace has its own state, so for workspaces
+// other than the default one, the pin is recorded under <stateId>/<workspace>. The state is read, and the pin logged,
+// using the given options.
+func CheckStatePin(stateId string, statePaths StatePaths, terragruntOptions *options.TerragruntOptions) error {
+	_, state, err := statePaths.readCurrentState(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	terragruntHomeDir, err := util.GetTerragruntHomeDir()
+	if err != nil {
+		return err
+	}
+
+	return checkAndUpdateStatePin(statePinId(stateId, statePaths), state, filepath.Join(terragruntHomeDir, STATE_PINS_DIR_NAME), terragruntOptions)
+}
+
+// Return the id under which to record the state pin for the given state id and state paths
+func statePinId(stateId string, statePaths StatePaths) string {
+	if statePaths.IsDefaultWorkspace() {
+		return stateId
+	}
+
+	return fmt.Sprintf("%s/%s", stateId, statePaths.Workspace)
+}
+
+// Return the path of the file in the given pins folder that holds the pin for the given state id. State ids may
+// contain characters that aren't allowed in file names, so the file is named after a hash of the id.
+func statePinPath(stateId string, pinsDir string) string {
+	sum := sha1.Sum([]byte(stateId))
+	return filepath.Join(pinsDir, hex.EncodeToString(sum[:]) + ".json")
+}
+
+// Check the lineage and serial of the given state against the pin for the given state id in the given pins folder, and
+// update the pin with the latest serial
+func checkAndUpdateStatePin(stateId string, state *TerraformState, pinsDir string, terragruntOptions *options.TerragruntOptions) error {
+	// Older versions of Terraform do not write a lineage, so there is nothing we can check
+	if state == nil || state.Lineage == "" {
+		return nil
+	}
+
+	pinPath := statePinPath(stateId, pinsDir)
+
+	pin, err := readStatePin(pinPath)
+	if err != nil {
+		return err
+	}
+
+	if pin != nil {
+		if pin.Lineage != state.Lineage {
+			return errors.WithStackTrace(StateLineageMismatch{StateFileId: stateId, ExpectedLineage: pin.Lineage, ActualLineage: state.Lineage, PinPath: pinPath})
+		}
+
+		if state.Serial < pin.Serial {
+			return errors.WithStackTrace(StateSerialWentBackwards{StateFileId: stateId, ExpectedMinSerial: pin.Serial, ActualSerial: state.Serial, PinPath: pinPath})
+		}
+
+		if state.Serial == pin.Serial {
+			return nil
+		}
+	} else {
+		terragruntOptions.Logger.Printf("Recording lineage %s for state %s in %s", state.Lineage, stateId, pinPath)
+	}
+
+	return writeStatePin(StatePin{StateId: stateId, Lineage: state.Lineage, Serial: state.Serial}, pinPath)
+}
+
+// Read the state pin from the given file. If the file doesn't exist, return nil.
+func readStatePin(pinPath string) (*StatePin, error) {
+	if !util.FileExists(pinPath) {
+		return nil, nil
+	}
+
+	bytes, err := ioutil.ReadFile(pinPath)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	pin := &StatePin{}
+	if err := json.Unmarshal(bytes, pin); err != nil {
+		return nil, errors.WithStackTrace(CantParseStatePinFile{Path: pinPath, UnderlyingErr: err})
+	}
+
+	return pin, nil
+}
+
+// Write the given state pin to the given file. To avoid leaving a half-written file behind if several Terragrunt
+// processes check the same state at once, we write to a temp file first and then rename it.
+func writeStatePin(pin StatePin, pinPath string) error {
+	bytes, err := json.MarshalIndent(pin, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pinPath), 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(pinPath), filepath.Base(pinPath))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(bytes); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return errors.WithStackTrace(os.Rename(tmpFile.Name(), pinPath))
+}
+
+type StateLineageMismatch struct {
+	StateFileId     string
+	ExpectedLineage string
+	ActualLineage   string
+	PinPath         string
+}
+
+func (err StateLineageMismatch) Error() string {
+	return fmt.Sprintf("The Terraform state for %s has lineage %s, but Terragrunt previously saw lineage %s. This usually means your remote state settings point at the state of a different set of templates. If this change is intentional, delete %s.", err.StateFileId, err.ActualLineage, err.ExpectedLineage, err.PinPath)
+}
+
+type StateSerialWentBackwards struct {
+	StateFileId       string
+	ExpectedMinSerial int
+	ActualSerial      int
+	PinPath           string
+}
+
+func (err StateSerialWentBackwards) Error() string {
+	return fmt.Sprintf("The Terraform state for %s has serial %d, but Terragrunt previously saw serial %d. Your state may be out of date or may have been overwritten with an older copy. If this is intentional, delete %s.", err.StateFileId, err.ActualSerial, err.ExpectedMinSerial, err.PinPath)
+}
+
+type CantParseStatePinFile struct {
+	Path          string
+	UnderlyingErr error
+}
+
+func (err CantParseStatePinFile) Error() string {
+	return fmt.Sprintf("Error parsing state pin file %s: %s", err.Path, err.UnderlyingErr.Error())
+}
@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"reflect"
+	"github.com/gruntwork-io/terragrunt/options"
+)
+
+func TestCheckAndUpdateStatePinNoState(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinsDir := filepath.Join(tmpDir, STATE_PINS_DIR_NAME)
+
+	assert.Nil(t, checkAndUpdateStatePin("my-app", nil, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+	assert.Nil(t, checkAndUpdateStatePin("my-app", &TerraformState{Serial: 3}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+
+	pin, err := readStatePin(statePinPath("my-app", pinsDir))
+	assert.Nil(t, err)
+	assert.Nil(t, pin)
+}
+
+func TestCheckAndUpdateStatePinRecordsAndUpdatesPin(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinsDir := filepath.Join(tmpDir, "nested", STATE_PINS_DIR_NAME)
+
+	assert.Nil(t, checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-1", Serial: 3}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+	assert.Nil(t, checkAndUpdateStatePin("other-app", &TerraformState{Lineage: "lineage-2", Serial: 1}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+	assert.Nil(t, checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-1", Serial: 5}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+
+	pin, err := readStatePin(statePinPath("my-app", pinsDir))
+	assert.Nil(t, err)
+	assert.Equal(t, &StatePin{StateId: "my-app", Lineage: "lineage-1", Serial: 5}, pin)
+
+	pin, err = readStatePin(statePinPath("other-app", pinsDir))
+	assert.Nil(t, err)
+	assert.Equal(t, &StatePin{StateId: "other-app", Lineage: "lineage-2", Serial: 1}, pin)
+}
+
+func TestCheckAndUpdateStatePinConcurrently(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinsDir := filepath.Join(tmpDir, STATE_PINS_DIR_NAME)
+	stateIds := []string{}
+	for i := 0; i < 20; i++ {
+		stateIds = append(stateIds, fmt.Sprintf("app-%d", i))
+	}
+
+	var waitGroup sync.WaitGroup
+	for _, stateId := range stateIds {
+		waitGroup.Add(1)
+		go func(stateId string) {
+			defer waitGroup.Done()
+			assert.Nil(t, checkAndUpdateStatePin(stateId, &TerraformState{Lineage: "lineage-" + stateId, Serial: 1}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+		}(stateId)
+	}
+	waitGroup.Wait()
+
+	for _, stateId := range stateIds {
+		pin, err := readStatePin(statePinPath(stateId, pinsDir))
+		assert.Nil(t, err)
+		assert.Equal(t, &StatePin{StateId: stateId, Lineage: "lineage-" + stateId, Serial: 1}, pin, "No pin should be lost when several states are checked at once")
+	}
+}
+
+func TestCheckAndUpdateStatePinLineageMismatch(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinsDir := filepath.Join(tmpDir, STATE_PINS_DIR_NAME)
+
+	assert.Nil(t, checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-1", Serial: 3}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+
+	err := checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-2", Serial: 10}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test"))
+	expectedErr := StateLineageMismatch{StateFileId: "my-app", ExpectedLineage: "lineage-1", ActualLineage: "lineage-2", PinPath: statePinPath("my-app", pinsDir)}
+	assert.True(t, errors.IsError(err, expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+
+	pin, err := readStatePin(statePinPath("my-app", pinsDir))
+	assert.Nil(t, err)
+	assert.Equal(t, &StatePin{StateId: "my-app", Lineage: "lineage-1", Serial: 3}, pin, "A failed check should not update the pin")
+}
+
+func TestCheckAndUpdateStatePinSerialWentBackwards(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinsDir := filepath.Join(tmpDir, STATE_PINS_DIR_NAME)
+
+	assert.Nil(t, checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-1", Serial: 3}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test")))
+
+	err := checkAndUpdateStatePin("my-app", &TerraformState{Lineage: "lineage-1", Serial: 2}, pinsDir, options.NewTerragruntOptionsForTest("state_pin_test"))
+	expectedErr := StateSerialWentBackwards{StateFileId: "my-app", ExpectedMinSerial: 3, ActualSerial: 2, PinPath: statePinPath("my-app", pinsDir)}
+	assert.True(t, errors.IsError(err, expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestReadStatePinInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	pinPath := writeFile(t, tmpDir, "pin.json", "not-valid-json")
+
+	_, err := readStatePin(pinPath)
+	_, isParseErr := errors.Unwrap(err).(CantParseStatePinFile)
+	assert.True(t, isParseErr, "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
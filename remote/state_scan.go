@@ -0,0 +1,233 @@
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// When storing state locally, Terraform keeps the previous version of the state in a file with this suffix
+const LOCAL_STATE_BACKUP_FILE_SUFFIX = ".backup"
+
+// Used in place of a file path for values found in the remote state
+const REMOTE_STATE_FILE_DESCRIPTION = "<remote state>"
+
+// A value in a Terraform state file that looks like a secret
+type SensitiveValue struct {
+	StateFile string `json:"stateFile"`
+	Module    string `json:"module"`
+	Resource  string `json:"resource,omitempty"`
+	Attribute string `json:"attribute,omitempty"`
+	Output    string `json:"output,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// A Terraform state file stored in the current folder
+type LocalStateFile struct {
+	Path         string `json:"path"`
+	TrackedByGit bool   `json:"trackedByGit"`
+}
+
+// The result of scanning the Terraform state for secrets
+type StateScanReport struct {
+	LocalStateFiles []LocalStateFile `json:"localStateFiles"`
+	SensitiveValues []SensitiveValue `json:"sensitiveValues"`
+}
+
+// Scan the local state files at the given paths and, if remote state is configured, the current remote state for
+// values that look like secrets. The remote state is read using the given options.
+func ScanStateFiles(statePaths StatePaths, terragruntOptions *options.TerragruntOptions) (*StateScanReport, error) {
+	report := &StateScanReport{LocalStateFiles: FindLocalStateFiles(statePaths.LocalStateFile), SensitiveValues: []SensitiveValue{}}
+
+	for _, localStateFile := range report.LocalStateFiles {
+		state, err := ParseTerraformStateFile(localStateFile.Path)
+		if err != nil {
+			return nil, err
+		}
+		report.SensitiveValues = append(report.SensitiveValues, ScanTerraformState(state, localStateFile.Path)...)
+	}
+
+	if statePaths.FindStateFile() == statePaths.RemoteStateFile {
+		_, state, err := statePaths.readCurrentState(terragruntOptions)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			report.SensitiveValues = append(report.SensitiveValues, ScanTerraformState(state, REMOTE_STATE_FILE_DESCRIPTION)...)
+		}
+	}
+
+	return report, nil
+}
+
+// Return an error if the given scan report shows secrets at risk of being committed to version control: that is, if
+// any local state file is tracked by Git, or if any local state file contains values that look like secrets. Values
+// found in the remote state are only reported, as remote state is where such values are expected to live.
+func (report *StateScanReport) Check() error {
+	trackedFiles := []string{}
+	for _, localStateFile := range report.LocalStateFiles {
+		if localStateFile.TrackedByGit {
+			trackedFiles = append(trackedFiles, localStateFile.Path)
+		}
+	}
+
+	if len(trackedFiles) > 0 {
+		return errors.WithStackTrace(StateFilesTrackedByGit(strings.Join(trackedFiles, ", ")))
+	}
+
+	localValues := 0
+	for _, sensitiveValue := range report.SensitiveValues {
+		if sensitiveValue.StateFile != REMOTE_STATE_FILE_DESCRIPTION {
+			localValues++
+		}
+	}
+
+	if localValues > 0 {
+		return errors.WithStackTrace(SensitiveValuesInLocalState(localValues))
+	}
+
+	return nil
+}
+
+// Log a warning for each local state file at the given paths with the logger of the given options. This should be called
+// when the Terragrunt config enables remote state, in which case local state files are usually left over and may
+// contain secrets. If the remote state file exists, the local state files are ignored, and the warning says so.
+func WarnAboutLocalStateFiles(statePaths StatePaths, terragruntOptions *options.TerragruntOptions) {
+	for _, localStateFile := range FindLocalStateFiles(statePaths.LocalStateFile) {
+		terragruntOptions.Logger.Printf("WARNING: found local state file %s even though remote state is configured in .terragrunt. State files may contain secrets, so you should delete it once you've confirmed your remote state is up to date.", localStateFile.Path)
+		if util.FileExists(statePaths.RemoteStateFile) {
+			terragruntOptions.Logger.Printf("WARNING: Terragrunt and Terraform use the remote state and ignore %s, so it may be out of date.", localStateFile.Path)
+		}
+		if localStateFile.TrackedByGit {
+			terragruntOptions.Logger.Printf("WARNING: %s is tracked by Git! Remove it from version control with 'git rm --cached %s'.", localStateFile.Path, localStateFile.Path)
+		}
+	}
+}
+
+// Return the given local state file and the backup Terraform keeps of it, if they exist
+func FindLocalStateFiles(localStateFile string) []LocalStateFile {
+	localStateFiles := []LocalStateFile{}
+
+	for _, path := range []string{localStateFile, localStateFile + LOCAL_STATE_BACKUP_FILE_SUFFIX} {
+		if util.FileExists(path) {
+			localStateFiles = append(localStateFiles, LocalStateFile{Path: path, TrackedByGit: isTrackedByGit(path)})
+		}
+	}
+
+	return localStateFiles
+}
+
+// Return true if the file at the given path is tracked by Git. If Git is not installed or the file is not in a Git
+// repo, return false.
+func isTrackedByGit(path string) bool {
+	cmd := exec.Command("git", "ls-files", "--error-unmatch", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	return cmd.Run() == nil
+}
+
+// Return all the resource attributes and outputs in the given state that look like secrets. Outputs are flagged if they
+// are marked as sensitive or if their name looks sensitive; resource attributes are flagged if their name looks
+// sensitive. Empty values are ignored.
+func ScanTerraformState(state *TerraformState, stateFile string) []SensitiveValue {
+	sensitiveValues := []SensitiveValue{}
+
+	for _, module := range state.Modules {
+		modulePath := strings.Join(module.Path, ".")
+
+		for outputName, output := range module.Outputs {
+			if reason := sensitiveOutputReason(outputName, output); reason != "" {
+				sensitiveValues = append(sensitiveValues, SensitiveValue{StateFile: stateFile, Module: modulePath, Output: outputName, Reason: reason})
+			}
+		}
+
+		for resourceName, resource := range module.Resources {
+			for attributeName, value := range getResourceAttributes(resource) {
+				if util.SENSITIVE_KEY_REGEX.MatchString(attributeName) && !isEmptyValue(value) {
+					sensitiveValues = append(sensitiveValues, SensitiveValue{StateFile: stateFile, Module: modulePath, Resource: resourceName, Attribute: attributeName, Reason: "attribute name looks sensitive"})
+				}
+			}
+		}
+	}
+
+	sort.Sort(sensitiveValuesByLocation(sensitiveValues))
+	return sensitiveValues
+}
+
+// Return the reason the given output looks sensitive, or an empty string if it doesn't. Since Terraform 0.7, outputs
+// are stored as objects with "sensitive", "type", and "value" fields; before that, they were stored as plain strings.
+func sensitiveOutputReason(outputName string, output interface{}) string {
+	value := output
+
+	if outputMap, isMap := output.(map[string]interface{}); isMap {
+		if sensitive, _ := outputMap["sensitive"].(bool); sensitive {
+			return "output is marked as sensitive"
+		}
+		value = outputMap["value"]
+	}
+
+	if util.SENSITIVE_KEY_REGEX.MatchString(outputName) && !isEmptyValue(value) {
+		return "output name looks sensitive"
+	}
+
+	return ""
+}
+
+// Return the attributes of the primary instance of the given resource from a Terraform state file
+func getResourceAttributes(resource interface{}) map[string]interface{} {
+	resourceMap, isMap := resource.(map[string]interface{})
+	if !isMap {
+		return nil
+	}
+
+	primary, isMap := resourceMap["primary"].(map[string]interface{})
+	if !isMap {
+		return nil
+	}
+
+	attributes, _ := primary["attributes"].(map[string]interface{})
+	return attributes
+}
+
+// Return true if the given value from a Terraform state file is nil or an empty string
+func isEmptyValue(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+type sensitiveValuesByLocation []SensitiveValue
+
+func (values sensitiveValuesByLocation) Len() int      { return len(values) }
+func (values sensitiveValuesByLocation) Swap(i, j int) { values[i], values[j] = values[j], values[i] }
+func (values sensitiveValuesByLocation) Less(i, j int) bool {
+	left := values[i].location()
+	right := values[j].location()
+
+	for index := range left {
+		if left[index] != right[index] {
+			return left[index] < right[index]
+		}
+	}
+
+	return false
+}
+
+// Return the fields that identify where this value lives in a state file, in the order they should be sorted by
+func (value SensitiveValue) location() []string {
+	return []string{value.StateFile, value.Module, value.Resource, value.Attribute, value.Output}
+}
+
+type StateFilesTrackedByGit string
+
+func (paths StateFilesTrackedByGit) Error() string {
+	return fmt.Sprintf("The following state files are tracked by Git, but state files may contain secrets and should not be checked into version control: %s", string(paths))
+}
+
+type SensitiveValuesInLocalState int
+
+func (count SensitiveValuesInLocalState) Error() string {
+	return fmt.Sprintf("Found %d values that look like secrets in local state files", int(count))
+}
@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"github.com/hashicorp/go-version"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// In the Terraform versions folder, each version of Terraform is in a subfolder named after the version, e.g.
+// 0.9.3/terraform
+const TERRAFORM_BINARY_NAME = "terraform"
+
+// Return the path of the newest Terraform binary in the given versions folder that satisfies the given version
+// constraint, or an empty string if there is none, or if the folder doesn't exist. Subfolders whose names aren't
+// versions, or that don't contain a terraform binary, are ignored.
+func FindTerraformBinary(versionsDir string, versionConstraint string) (string, error) {
+	constraint, err := version.NewConstraint(versionConstraint)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	entries, err := ioutil.ReadDir(versionsDir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	var newestVersion *version.Version
+	newestBinary := ""
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		entryVersion, err := version.NewVersion(entry.Name())
+		if err != nil || !constraint.Check(entryVersion) {
+			continue
+		}
+
+		binary := filepath.Join(versionsDir, entry.Name(), TERRAFORM_BINARY_NAME)
+		if !util.FileExists(binary) {
+			continue
+		}
+
+		if newestVersion == nil || entryVersion.GreaterThan(newestVersion) {
+			newestVersion = entryVersion
+			newestBinary = binary
+		}
+	}
+
+	return newestBinary, nil
+}
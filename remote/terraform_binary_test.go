@@ -0,0 +1,48 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTerraformBinary(t *testing.T) {
+	t.Parallel()
+
+	versionsDir := createTempDir(t)
+	defer os.RemoveAll(versionsDir)
+
+	writeFile(t, filepath.Join(versionsDir, "0.8.8"), TERRAFORM_BINARY_NAME, "")
+	writeFile(t, filepath.Join(versionsDir, "0.9.3"), TERRAFORM_BINARY_NAME, "")
+	writeFile(t, filepath.Join(versionsDir, "0.9.11"), TERRAFORM_BINARY_NAME, "")
+	writeFile(t, filepath.Join(versionsDir, "0.10.0"), TERRAFORM_BINARY_NAME, "")
+	writeFile(t, filepath.Join(versionsDir, "0.9.20"), "README", "")
+	writeFile(t, filepath.Join(versionsDir, "latest"), TERRAFORM_BINARY_NAME, "")
+
+	testCases := []struct {
+		constraint string
+		expected   string
+	}{
+		{">= 0.9.0, < 0.10.0", filepath.Join(versionsDir, "0.9.11", TERRAFORM_BINARY_NAME)},
+		{"~> 0.9.1", filepath.Join(versionsDir, "0.9.11", TERRAFORM_BINARY_NAME)},
+		{"< 0.9.0", filepath.Join(versionsDir, "0.8.8", TERRAFORM_BINARY_NAME)},
+		{">= 0.9.0", filepath.Join(versionsDir, "0.10.0", TERRAFORM_BINARY_NAME)},
+		{"= 0.9.20", ""},
+		{">= 0.11.0", ""},
+	}
+
+	for _, testCase := range testCases {
+		binary, err := FindTerraformBinary(versionsDir, testCase.constraint)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, binary, "For constraint %s", testCase.constraint)
+	}
+}
+
+func TestFindTerraformBinaryNoVersionsDir(t *testing.T) {
+	t.Parallel()
+
+	binary, err := FindTerraformBinary("/this/path/does/not/exist", ">= 0.9.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "", binary)
+}
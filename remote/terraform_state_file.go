@@ -1,11 +1,13 @@
 package remote
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"github.com/gruntwork-io/terragrunt/errors"
 	"fmt"
-	"github.com/gruntwork-io/terragrunt/util"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
 )
 
 // TODO: this file could be changed to use the Terraform Go code to read state files, but that code is relatively
@@ -22,7 +24,9 @@ const DEFAULT_PATH_TO_REMOTE_STATE_FILE = ".terraform/terraform.tfstate"
 type TerraformState struct {
 	Version int
 	Serial  int
+	Lineage string
 	Remote  *TerraformStateRemote
+	Backend *TerraformBackend
 	Modules []TerraformStateModule
 }
 
@@ -32,6 +36,12 @@ type TerraformStateRemote struct {
 	Config map[string]interface{}
 }
 
+// The structure of the "backend" section of the .terraform/terraform.tfstate file used by Terraform 0.9 and above
+type TerraformBackend struct {
+	Type   string
+	Config map[string]interface{}
+}
+
 // The structure of a "module" section of the Terraform .tfstate file
 type TerraformStateModule struct {
 	Path      []string
@@ -44,16 +54,74 @@ func (state *TerraformState) IsRemote() bool {
 	return state.Remote != nil
 }
 
+// Return true if this is the .terraform/terraform.tfstate file used by Terraform 0.9 and above to record the backend
+// configuration. Note that such a file only contains the backend settings and not the actual Terraform state.
+func (state *TerraformState) IsBackend() bool {
+	return state.Backend != nil
+}
+
+// Return true if this Terraform state does not contain any resources or outputs
+func (state *TerraformState) IsEmpty() bool {
+	for _, module := range state.Modules {
+		if len(module.Resources) > 0 || len(module.Outputs) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Parse the Terraform .tfstate file from its default locations. If the file doesn't exist at any of the default
 // locations, return nil.
 func ParseTerraformStateFileFromDefaultLocations() (*TerraformState, error) {
-	if util.FileExists(DEFAULT_PATH_TO_LOCAL_STATE_FILE) {
-		return ParseTerraformStateFile(DEFAULT_PATH_TO_LOCAL_STATE_FILE)
-	} else if util.FileExists(DEFAULT_PATH_TO_REMOTE_STATE_FILE) {
-		return ParseTerraformStateFile(DEFAULT_PATH_TO_REMOTE_STATE_FILE)
-	} else {
-		return nil, nil
+	return DefaultStatePaths().ParseStateFile()
+}
+
+// Return the current Terraform state, both as raw data and parsed, or nil if there is no state. With Terraform 0.9 and
+// above, the remote state file only contains the backend settings, so in that case the actual state is fetched from
+// the backend by running "terraform state pull" with the given options.
+func (statePaths StatePaths) readCurrentState(terragruntOptions *options.TerragruntOptions) ([]byte, *TerraformState, error) {
+	path := statePaths.FindStateFile()
+	if path == "" {
+		return nil, nil, nil
 	}
+
+	data, state, err := readStateFile(path)
+	if err != nil || !state.IsBackend() {
+		return data, state, err
+	}
+
+	data, err = shell.RunShellCommandWithOptionsAndCaptureOutput(terragruntOptions, terragruntOptions.TerraformPath, "state", "pull")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Terraform prints nothing if there is no state in the backend yet
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil, nil
+	}
+
+	state, err = parseTerraformState(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, state, nil
+}
+
+// Read and parse the Terraform state file at the given path, returning both the raw data and the parsed state
+func readStateFile(path string) ([]byte, *TerraformState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(CantParseTerraformStateFile{Path: path, UnderlyingErr: err})
+	}
+
+	state, err := parseTerraformState(data)
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(CantParseTerraformStateFile{Path: path, UnderlyingErr: err})
+	}
+
+	return data, state, nil
 }
 
 // Parse the Terraform .tfstate file at the given path
@@ -291,6 +291,69 @@ func TestParseTerraformStateRemoteFull(t *testing.T) {
 	assert.True(t, actualTerraformState.IsRemote())
 }
 
+func TestParseTerraformStateBackend(t *testing.T) {
+	t.Parallel()
+
+	// This is the format of the .terraform/terraform.tfstate file in Terraform 0.9 and above
+	stateFile :=
+	`
+	{
+		"version": 3,
+		"serial": 0,
+		"lineage": "c1e1bc2a-f8a3-4bd6-b4e1-4d1c3a8ca6b6",
+		"backend": {
+			"type": "s3",
+			"config": {
+				"bucket": "bucket",
+				"encrypt": "true",
+				"key": "experiment-1.tfstate",
+				"region": "us-east-1"
+			},
+			"hash": 9876543210
+		},
+		"modules": [
+			{
+				"path": [
+					"root"
+				],
+				"outputs": {},
+				"resources": {},
+				"depends_on": []
+			}
+		]
+	}
+	`
+
+	expectedTerraformState := &TerraformState{
+		Version: 3,
+		Serial: 0,
+		Lineage: "c1e1bc2a-f8a3-4bd6-b4e1-4d1c3a8ca6b6",
+		Backend: &TerraformBackend{
+			Type: "s3",
+			Config: map[string]interface{}{
+				"bucket": "bucket",
+				"encrypt": "true",
+				"key": "experiment-1.tfstate",
+				"region": "us-east-1",
+			},
+		},
+		Modules: []TerraformStateModule{
+			TerraformStateModule{
+				Path: []string{"root"},
+				Outputs: map[string]interface{}{},
+				Resources: map[string]interface{}{},
+			},
+		},
+	}
+
+	actualTerraformState, err := parseTerraformState([]byte(stateFile))
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedTerraformState, actualTerraformState)
+	assert.False(t, actualTerraformState.IsRemote())
+	assert.True(t, actualTerraformState.IsBackend())
+}
+
 func TestParseTerraformStateEmpty(t *testing.T) {
 	t.Parallel()
 
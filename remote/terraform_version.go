@@ -0,0 +1,64 @@
+package remote
+
+import (
+	"fmt"
+	"regexp"
+	"github.com/hashicorp/go-version"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+)
+
+// Terraform 0.9 replaced the "terraform remote" commands with backend blocks and "terraform init"
+var FIRST_TERRAFORM_VERSION_WITH_BACKENDS = version.Must(version.NewVersion("0.9.0"))
+
+// The "terraform version" command prints the version on the first line in the format "Terraform v0.9.1"
+var TERRAFORM_VERSION_REGEX = regexp.MustCompile(`Terraform v(\S+)`)
+
+// Return the version of the Terraform binary of the given options. If the options don't have the version yet, run
+// "terraform version" to find it and store it in the options, so later calls don't have to run Terraform again.
+func GetTerraformVersion(terragruntOptions *options.TerragruntOptions) (*version.Version, error) {
+	if terragruntOptions.TerraformVersion != nil {
+		return terragruntOptions.TerraformVersion, nil
+	}
+
+	output, err := shell.RunShellCommandWithOptionsAndCaptureOutput(terragruntOptions, terragruntOptions.TerraformPath, "version")
+	if err != nil {
+		return nil, err
+	}
+
+	terraformVersion, err := parseTerraformVersion(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	terragruntOptions.TerraformVersion = terraformVersion
+	return terraformVersion, nil
+}
+
+// Parse the Terraform version out of the output of the "terraform version" command
+func parseTerraformVersion(versionCommandOutput string) (*version.Version, error) {
+	matches := TERRAFORM_VERSION_REGEX.FindStringSubmatch(versionCommandOutput)
+	if matches == nil {
+		return nil, errors.WithStackTrace(InvalidTerraformVersionSyntax(versionCommandOutput))
+	}
+
+	terraformVersion, err := version.NewVersion(matches[1])
+	if err != nil {
+		return nil, errors.WithStackTrace(InvalidTerraformVersionSyntax(versionCommandOutput))
+	}
+
+	return terraformVersion, nil
+}
+
+// Return true if the given version of Terraform configures remote state using backend blocks and "terraform init"
+// rather than "terraform remote config"
+func usesBackends(terraformVersion *version.Version) bool {
+	return !terraformVersion.LessThan(FIRST_TERRAFORM_VERSION_WITH_BACKENDS)
+}
+
+type InvalidTerraformVersionSyntax string
+
+func (output InvalidTerraformVersionSyntax) Error() string {
+	return fmt.Sprintf("Unable to parse Terraform version from the output of 'terraform version': %s", string(output))
+}
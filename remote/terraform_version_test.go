@@ -0,0 +1,52 @@
+package remote
+
+import (
+	"testing"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"reflect"
+)
+
+func TestParseTerraformVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		output   string
+		expected string
+		backends bool
+	}{
+		{"Terraform v0.7.13\n", "0.7.13", false},
+		{"Terraform v0.8.8\n\nYour version of Terraform is out of date! The latest version\nis 0.9.1.", "0.8.8", false},
+		{"Terraform v0.9.0\n", "0.9.0", true},
+		{"Terraform v0.9.2-dev (abc123)\n", "0.9.2-dev", true},
+		{"Terraform v0.10.0\n", "0.10.0", true},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := parseTerraformVersion(testCase.output)
+		assert.Nil(t, err, "For output %s", testCase.output)
+		assert.Equal(t, testCase.expected, actual.String(), "For output %s", testCase.output)
+		assert.Equal(t, testCase.backends, usesBackends(actual), "For output %s", testCase.output)
+	}
+}
+
+func TestParseTerraformVersionInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseTerraformVersion("not-terraform")
+	assert.True(t, errors.IsError(err, InvalidTerraformVersionSyntax("not-terraform")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+}
+
+func TestGetTerraformVersionUsesVersionInOptions(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest("terraform_version_test")
+	terragruntOptions.TerraformPath = "/this/terraform/does/not/exist"
+	terragruntOptions.TerraformVersion = version.Must(version.NewVersion("0.9.3"))
+
+	terraformVersion, err := GetTerraformVersion(terragruntOptions)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, "0.9.3", terraformVersion.String())
+}
@@ -3,27 +3,60 @@ package shell
 import (
 	"strings"
 	"fmt"
-	"os"
 	"bufio"
+	"io"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
 )
 
-// Prompt the user for text in the CLI. Returns the text entered by the user.
-func PromptUserForInput(prompt string) (string, error) {
-	fmt.Print(prompt)
-	reader := bufio.NewReader(os.Stdin)
+// Prompt the user for text in the CLI. Returns the text entered by the user. The prompt is written to the writer, and
+// the answer read from the reader, of the given options. If the options say Terragrunt is running non-interactively,
+// or the reader is at EOF (e.g. stdin is /dev/null in CI), return a PromptNotAllowed error instead of waiting for input
+// that will never come.
+func PromptUserForInput(prompt string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	if terragruntOptions.NonInteractive {
+		return "", errors.WithStackTrace(PromptNotAllowed(prompt))
+	}
+
+	fmt.Fprint(terragruntOptions.Writer, prompt)
+	reader := bufio.NewReader(terragruntOptions.Reader)
 
 	text, err := reader.ReadString('\n')
-	if err != nil {
+	if err == io.EOF && text == "" {
+		return "", errors.WithStackTrace(PromptNotAllowed(prompt))
+	}
+	if err != nil && err != io.EOF {
 		return "", errors.WithStackTrace(err)
 	}
 
 	return strings.TrimSpace(text), nil
 }
 
-// Prompt the user for a yes/no response and return true if they entered yes.
-func PromptUserForYesNo(prompt string) (bool, error) {
-	resp, err := PromptUserForInput(fmt.Sprintf("%s (y/n) ", prompt))
+// The answer a yes/no prompt gets when Terragrunt is running non-interactively
+type NonInteractiveAnswer int
+
+const (
+	// There is no safe answer, so the prompt fails with a PromptNotAllowed error
+	NO_SAFE_ANSWER NonInteractiveAnswer = iota
+	ANSWER_YES
+)
+
+// Prompt the user for a yes/no response and return true if they entered yes. If the options say Terragrunt is running
+// non-interactively, or there is no input to read, return the given answer instead, or a PromptNotAllowed error if
+// there is no safe answer.
+func PromptUserForYesNo(prompt string, nonInteractiveAnswer NonInteractiveAnswer, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	if terragruntOptions.NonInteractive && nonInteractiveAnswer == ANSWER_YES {
+		terragruntOptions.Logger.Printf("Running non-interactively, so answering yes to: %s", prompt)
+		return true, nil
+	}
+
+	promptWithChoices := fmt.Sprintf("%s (y/n) ", prompt)
+	resp, err := PromptUserForInput(promptWithChoices, terragruntOptions)
+
+	if errors.IsError(err, PromptNotAllowed(promptWithChoices)) && nonInteractiveAnswer == ANSWER_YES {
+		terragruntOptions.Logger.Printf("No input to read, so answering yes to: %s", prompt)
+		return true, nil
+	}
 
 	if err != nil {
 		return false, errors.WithStackTrace(err)
@@ -34,3 +67,9 @@ func PromptUserForYesNo(prompt string) (bool, error) {
 	default: return false, nil
 	}
 }
+
+type PromptNotAllowed string
+
+func (prompt PromptNotAllowed) Error() string {
+	return fmt.Sprintf("Terragrunt is running non-interactively or there is no input to read (e.g. stdin is not a terminal in CI), so it cannot ask, and there is no safe default answer to: %s", strings.TrimSpace(string(prompt)))
+}
@@ -0,0 +1,46 @@
+package shell
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptUserForYesNo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		nonInteractive       bool
+		input                string
+		nonInteractiveAnswer NonInteractiveAnswer
+		expectedAnswer       bool
+		expectPromptError    bool
+	}{
+		{false, "y\n", NO_SAFE_ANSWER, true, false},
+		{false, "yes", NO_SAFE_ANSWER, true, false},
+		{false, "n\n", ANSWER_YES, false, false},
+		{false, "", NO_SAFE_ANSWER, false, true},
+		{false, "", ANSWER_YES, true, false},
+		{true, "y\n", NO_SAFE_ANSWER, false, true},
+		{true, "n\n", ANSWER_YES, true, false},
+	}
+
+	for _, testCase := range testCases {
+		terragruntOptions := options.NewTerragruntOptionsForTest("prompt_test")
+		terragruntOptions.NonInteractive = testCase.nonInteractive
+		terragruntOptions.Reader = strings.NewReader(testCase.input)
+		terragruntOptions.Writer = &bytes.Buffer{}
+
+		answer, err := PromptUserForYesNo("Proceed?", testCase.nonInteractiveAnswer, terragruntOptions)
+		if testCase.expectPromptError {
+			assert.True(t, errors.IsError(err, PromptNotAllowed("Proceed? (y/n) ")), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+		} else {
+			assert.Nil(t, err, "Unexpected error for %v: %v", testCase, err)
+			assert.Equal(t, testCase.expectedAnswer, answer, "For %v", testCase)
+		}
+	}
+}
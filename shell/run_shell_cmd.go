@@ -1,23 +1,51 @@
 package shell
 
 import (
-	"os"
 	"os/exec"
-	"strings"
 	"github.com/gruntwork-io/terragrunt/util"
 	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
 )
 
-// Run the specified shell command with the specified arguments. Connect the command's stdin, stdout, and stderr to
-// the currently running app.
-func RunShellCommand(command string, args ... string) error {
-	util.Logger.Printf("Running command: %s %s", command, strings.Join(args, " "))
+// Run the specified shell command with the specified arguments in the working directory of the given options.
+// Connect the command's stdin, stdout, and stderr to the reader and writers of the options, and pass it their
+// environment variables.
+func RunShellCommandWithOptions(terragruntOptions *options.TerragruntOptions, command string, args ... string) error {
+	cmd := newCommandWithOptions(terragruntOptions, command, args...)
+	cmd.Stdout = terragruntOptions.Writer
+
+	return errors.WithStackTrace(cmd.Run())
+}
+
+// Run the specified shell command with the specified arguments in the working directory of the given options and
+// return its stdout as a byte slice. Connect the command's stdin and stderr to the reader and error writer of the
+// options, and pass it their environment variables.
+func RunShellCommandWithOptionsAndCaptureOutput(terragruntOptions *options.TerragruntOptions, command string, args ... string) ([]byte, error) {
+	cmd := newCommandWithOptions(terragruntOptions, command, args...)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return out, nil
+}
+
+// Log the specified shell command and create it, set up to run in the working directory of the given options, with
+// their environment variables, reading from their reader, and writing errors to their error writer
+func newCommandWithOptions(terragruntOptions *options.TerragruntOptions, command string, args ... string) *exec.Cmd {
+	if terragruntOptions.WorkingDir == "" {
+		terragruntOptions.Logger.Printf("Running command: %s", util.RedactCommand(command, args))
+	} else {
+		terragruntOptions.Logger.Printf("Running command in %s: %s", terragruntOptions.WorkingDir, util.RedactCommand(command, args))
+	}
 
 	cmd := exec.Command(command, args...)
 
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdin = terragruntOptions.Reader
+	cmd.Stderr = terragruntOptions.ErrWriter
+	cmd.Env = terragruntOptions.EnvironmentList()
+	cmd.Dir = terragruntOptions.WorkingDir
 
-	return errors.WithStackTrace(cmd.Run())
-}
\ No newline at end of file
+	return cmd
+}
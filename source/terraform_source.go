@@ -0,0 +1,415 @@
+package source
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"github.com/gruntwork-io/terragrunt/shell"
+	"github.com/gruntwork-io/terragrunt/util"
+)
+
+// Sources that start with this prefix are Git repositories
+const GIT_SOURCE_PREFIX = "git::"
+
+// Separates the URL or path of a source from the folder within it that contains the Terraform templates, e.g.
+// git::https://github.com/foo/modules.git//app
+const SUBDIR_SEPARATOR = "//"
+
+// Terragrunt records the source it downloaded in this file in the download folder, so it knows if it can reuse the
+// download next time
+const SOURCE_VERSION_FILE = ".terragrunt-source-version"
+
+// Terraform keeps local state in files, and a folder of workspace states, whose names match this pattern, e.g.
+// terraform.tfstate, terraform.tfstate.backup, and terraform.tfstate.d
+const STATE_FILES_PATTERN = "*.tfstate*"
+
+// Terraform keeps downloaded modules and the remote state config in this folder
+const TERRAFORM_DATA_DIR = ".terraform"
+
+// A parsed terraform.source setting
+type TerraformSource struct {
+	// True if the source is a Git repository, false if it's a folder on the local file system
+	IsGit  bool
+	// The URL of the Git repository or the absolute path of the local folder
+	Repo   string
+	// The folder within the repository or local folder that contains the Terraform templates, if any
+	Subdir string
+	// The Git branch, tag, or commit to check out, if any
+	Ref    string
+}
+
+// Parse the given terraform.source setting. Git sources have the form git::<url>//<subdir>?ref=<ref>, where the
+// subdir and ref are optional. Any other source is a local path, optionally followed by //<subdir>; a relative local
+// path is relative to the given working directory.
+func ParseTerraformSource(sourceUrl string, workingDir string) (*TerraformSource, error) {
+	if strings.HasPrefix(sourceUrl, GIT_SOURCE_PREFIX) {
+		return parseGitSource(strings.TrimPrefix(sourceUrl, GIT_SOURCE_PREFIX))
+	}
+
+	if strings.Contains(sourceUrl, "://") {
+		return nil, errors.WithStackTrace(UnsupportedSource(sourceUrl))
+	}
+
+	path, subdir := splitSubdir(sourceUrl)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return &TerraformSource{Repo: absPath, Subdir: subdir}, nil
+}
+
+// Parse the given Git source, without the git:: prefix
+func parseGitSource(sourceUrl string) (*TerraformSource, error) {
+	query := ""
+	if i := strings.Index(sourceUrl, "?"); i >= 0 {
+		sourceUrl, query = sourceUrl[:i], sourceUrl[i + 1:]
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	for name := range params {
+		if name != "ref" {
+			return nil, errors.WithStackTrace(UnsupportedSourceParam(name))
+		}
+	}
+
+	repo, subdir := splitSubdir(sourceUrl)
+	if repo == "" {
+		return nil, errors.WithStackTrace(UnsupportedSource(GIT_SOURCE_PREFIX + sourceUrl))
+	}
+
+	return &TerraformSource{IsGit: true, Repo: repo, Subdir: subdir, Ref: params.Get("ref")}, nil
+}
+
+// Split the given source URL or path into the part before the // that separates it from the subdir, and the subdir.
+// The // of a URL scheme, such as https://, does not count.
+func splitSubdir(sourceUrl string) (string, string) {
+	start := 0
+	if i := strings.Index(sourceUrl, "://"); i >= 0 {
+		start = i + len("://")
+	}
+
+	i := strings.Index(sourceUrl[start:], SUBDIR_SEPARATOR)
+	if i < 0 {
+		return sourceUrl, ""
+	}
+
+	return sourceUrl[:start + i], strings.Trim(sourceUrl[start + i + len(SUBDIR_SEPARATOR):], "/")
+}
+
+// Replace the given source with the given override, which is typically a local checkout of the same repository, used
+// while developing the Terraform templates. The subdir of the source, if any, is appended to the override.
+func OverrideTerraformSource(sourceUrl string, override string) string {
+	_, subdir := splitSubdir(strings.TrimPrefix(sourceUrl, GIT_SOURCE_PREFIX))
+	if i := strings.Index(subdir, "?"); i >= 0 {
+		subdir = subdir[:i]
+	}
+
+	if subdir == "" {
+		return override
+	}
+
+	return strings.TrimRight(override, "/") + SUBDIR_SEPARATOR + subdir
+}
+
+// Return this source as a string that identifies exactly what was downloaded
+func (terraformSource *TerraformSource) String() string {
+	str := terraformSource.Repo
+
+	if terraformSource.IsGit {
+		str = GIT_SOURCE_PREFIX + str
+	}
+
+	if terraformSource.Subdir != "" {
+		str = str + SUBDIR_SEPARATOR + terraformSource.Subdir
+	}
+
+	if terraformSource.Ref != "" {
+		str = str + "?ref=" + terraformSource.Ref
+	}
+
+	return str
+}
+
+// Download the Terraform templates for the working directory in the given options from the given source into a folder
+// in the download dir of the options, copy the files in the working directory (such as .terragrunt and .tfvars files)
+// into it, and return the folder in which to run Terraform. Each working directory gets its own download folder, so
+// folders that use the same source don't share the .terraform folder. A Git source checked out at a ref is only
+// downloaded again if the source changes; a local source or a Git source without a ref is copied every time, as it
+// may have changed, but the .terraform folder is kept. Local state files in the working directory are copied too, so
+// use CopyStateFiles to copy them back once Terraform is done.
+func DownloadTerraformSource(sourceUrl string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	workingDir, err := filepath.Abs(terragruntOptions.WorkingDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	terraformSource, err := ParseTerraformSource(sourceUrl, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	downloadDir := filepath.Join(terragruntOptions.DownloadDir, hash(workingDir))
+
+	if isAlreadyDownloaded(terraformSource, downloadDir) {
+		terragruntOptions.Logger.Printf("Terraform source %s is already downloaded to %s", terraformSource, downloadDir)
+	} else if err := download(terraformSource, downloadDir, terragruntOptions); err != nil {
+		return "", err
+	}
+
+	terraformDir := terraformDirForSource(terraformSource, downloadDir)
+	if !util.FileExists(terraformDir) {
+		return "", errors.WithStackTrace(SourceSubdirNotFound{Source: terraformSource.String(), Subdir: terraformSource.Subdir})
+	}
+
+	terragruntOptions.Logger.Printf("Copying files from %s into %s", workingDir, terraformDir)
+	if err := copyFilesInDir(workingDir, terraformDir); err != nil {
+		return "", err
+	}
+
+	if err := CopyStateFiles(workingDir, terraformDir); err != nil {
+		return "", err
+	}
+
+	return terraformDir, nil
+}
+
+// Return the folder in which Terraform runs for the working directory in the given options when its templates come
+// from the given source, as returned by DownloadTerraformSource, without downloading anything. The folder only exists
+// once the source has been downloaded.
+func FindTerraformDir(sourceUrl string, terragruntOptions *options.TerragruntOptions) (string, error) {
+	workingDir, err := filepath.Abs(terragruntOptions.WorkingDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	terraformSource, err := ParseTerraformSource(sourceUrl, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	return terraformDirForSource(terraformSource, filepath.Join(terragruntOptions.DownloadDir, hash(workingDir))), nil
+}
+
+// Return the folder in which Terraform runs when the given source is downloaded into the given download dir
+func terraformDirForSource(terraformSource *TerraformSource, downloadDir string) string {
+	return filepath.Join(downloadDir, terraformSource.Subdir)
+}
+
+// Copy the local state files, and the folder of workspace states, in the given source folder into the given
+// destination folder, overwriting any that are already there. Terragrunt uses this to copy the state Terraform wrote in
+// the download folder back to the working directory, so it isn't lost when the source is downloaded again.
+func CopyStateFiles(sourceDir string, destDir string) error {
+	paths, err := filepath.Glob(filepath.Join(sourceDir, STATE_FILES_PATTERN))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		destPath := filepath.Join(destDir, info.Name())
+
+		if info.IsDir() {
+			if err := copyDir(path, destPath); err != nil {
+				return err
+			}
+		} else if err := copyFile(path, destPath, info.Mode()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return true if the given source was already downloaded to the given folder and can't have changed since
+func isAlreadyDownloaded(terraformSource *TerraformSource, downloadDir string) bool {
+	if !terraformSource.IsGit || terraformSource.Ref == "" {
+		return false
+	}
+
+	bytes, err := ioutil.ReadFile(filepath.Join(downloadDir, SOURCE_VERSION_FILE))
+	return err == nil && string(bytes) == terraformSource.String()
+}
+
+// Download the given source into the given folder, replacing anything that was in it before except the .terraform
+// folder of the templates, so Terraform doesn't have to download modules and configure remote state again, and record
+// what was downloaded in the source version file
+func download(terraformSource *TerraformSource, downloadDir string, terragruntOptions *options.TerragruntOptions) error {
+	terragruntOptions.Logger.Printf("Downloading Terraform source %s to %s", terraformSource, downloadDir)
+
+	terraformDataDir := filepath.Join(downloadDir, terraformSource.Subdir, TERRAFORM_DATA_DIR)
+	savedTerraformDataDir := downloadDir + TERRAFORM_DATA_DIR
+
+	if err := os.RemoveAll(savedTerraformDataDir); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if util.FileExists(terraformDataDir) {
+		if err := os.Rename(terraformDataDir, savedTerraformDataDir); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	if err := os.RemoveAll(downloadDir); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if err := downloadSource(terraformSource, downloadDir, terragruntOptions); err != nil {
+		return err
+	}
+
+	if util.FileExists(savedTerraformDataDir) && util.FileExists(filepath.Dir(terraformDataDir)) {
+		if err := os.Rename(savedTerraformDataDir, terraformDataDir); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+
+	return errors.WithStackTrace(ioutil.WriteFile(filepath.Join(downloadDir, SOURCE_VERSION_FILE), []byte(terraformSource.String()), 0644))
+}
+
+// Clone or copy the given source into the given folder, which must not exist yet
+func downloadSource(terraformSource *TerraformSource, downloadDir string, terragruntOptions *options.TerragruntOptions) error {
+
+	if terraformSource.IsGit {
+		if err := cloneGitRepo(terraformSource, downloadDir, terragruntOptions); err != nil {
+			return err
+		}
+	} else {
+		if !util.FileExists(terraformSource.Repo) {
+			return errors.WithStackTrace(SourceNotFound(terraformSource.Repo))
+		}
+		if err := copyDir(terraformSource.Repo, downloadDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clone the Git repository of the given source into the given folder and check out its ref, if it has one
+func cloneGitRepo(terraformSource *TerraformSource, downloadDir string, terragruntOptions *options.TerragruntOptions) error {
+	gitOptions := terragruntOptions.Clone()
+	gitOptions.WorkingDir = ""
+	gitOptions.Writer = terragruntOptions.ErrWriter
+
+	if err := shell.RunShellCommandWithOptions(gitOptions, "git", "clone", "--quiet", terraformSource.Repo, downloadDir); err != nil {
+		return errors.WithStackTraceAndPrefix(err, "Error cloning %s", terraformSource.Repo)
+	}
+
+	if terraformSource.Ref == "" {
+		return nil
+	}
+
+	gitOptions.WorkingDir = downloadDir
+	if err := shell.RunShellCommandWithOptions(gitOptions, "git", "checkout", "--quiet", terraformSource.Ref); err != nil {
+		return errors.WithStackTraceAndPrefix(err, "Error checking out %s in %s", terraformSource.Ref, terraformSource.Repo)
+	}
+
+	return nil
+}
+
+// Copy the contents of the given source folder into the given destination folder, recursively. The .git and
+// .terraform folders are skipped.
+func copyDir(sourceDir string, destDir string) error {
+	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		relativePath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		destPath := filepath.Join(destDir, relativePath)
+
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return errors.WithStackTrace(os.MkdirAll(destPath, info.Mode()))
+		}
+
+		return copyFile(path, destPath, info.Mode())
+	})
+}
+
+// Copy the regular files directly in the given source folder, but not its subfolders, into the given destination
+// folder, overwriting any files with the same name
+func copyFilesInDir(sourceDir string, destDir string) error {
+	files, err := ioutil.ReadDir(sourceDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	for _, file := range files {
+		if file.Mode().IsRegular() {
+			if err := copyFile(filepath.Join(sourceDir, file.Name()), filepath.Join(destDir, file.Name()), file.Mode()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Copy the file at the given source path to the given destination path
+func copyFile(sourcePath string, destPath string, mode os.FileMode) error {
+	bytes, err := ioutil.ReadFile(sourcePath)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	return errors.WithStackTrace(ioutil.WriteFile(destPath, bytes, mode))
+}
+
+// Return a hash of the given string that can be used as a folder name
+func hash(str string) string {
+	sum := sha1.Sum([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
+
+type UnsupportedSource string
+
+func (sourceUrl UnsupportedSource) Error() string {
+	return fmt.Sprintf("Unsupported Terraform source %s. Use a local path or a Git repository (git::<url>//<subdir>?ref=<ref>).", string(sourceUrl))
+}
+
+type UnsupportedSourceParam string
+
+func (name UnsupportedSourceParam) Error() string {
+	return fmt.Sprintf("Unsupported parameter %s in Terraform source. The only supported parameter is ref.", string(name))
+}
+
+type SourceNotFound string
+
+func (path SourceNotFound) Error() string {
+	return fmt.Sprintf("The Terraform source folder %s does not exist", string(path))
+}
+
+type SourceSubdirNotFound struct {
+	Source string
+	Subdir string
+}
+
+func (err SourceSubdirNotFound) Error() string {
+	return fmt.Sprintf("Could not find the folder %s in Terraform source %s", err.Subdir, err.Source)
+}
@@ -0,0 +1,225 @@
+package source
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/gruntwork-io/terragrunt/errors"
+	"github.com/gruntwork-io/terragrunt/options"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+)
+
+func TestParseTerraformSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl string
+		expected  TerraformSource
+	}{
+		{"git::https://github.com/foo/modules.git", TerraformSource{IsGit: true, Repo: "https://github.com/foo/modules.git"}},
+		{"git::https://github.com/foo/modules.git//app", TerraformSource{IsGit: true, Repo: "https://github.com/foo/modules.git", Subdir: "app"}},
+		{"git::https://github.com/foo/modules.git//modules/app?ref=v1.2", TerraformSource{IsGit: true, Repo: "https://github.com/foo/modules.git", Subdir: "modules/app", Ref: "v1.2"}},
+		{"git::https://github.com/foo/modules.git?ref=master", TerraformSource{IsGit: true, Repo: "https://github.com/foo/modules.git", Ref: "master"}},
+		{"git::git@github.com:foo/modules.git//app?ref=v1", TerraformSource{IsGit: true, Repo: "git@github.com:foo/modules.git", Subdir: "app", Ref: "v1"}},
+		{"git::file:///repos/modules//app", TerraformSource{IsGit: true, Repo: "file:///repos/modules", Subdir: "app"}},
+		{"../modules/app", TerraformSource{Repo: "/work/modules/app"}},
+		{"../modules//app", TerraformSource{Repo: "/work/modules", Subdir: "app"}},
+		{"/repos/modules//app/", TerraformSource{Repo: "/repos/modules", Subdir: "app"}},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := ParseTerraformSource(testCase.sourceUrl, "/work/live")
+		assert.Nil(t, err, "For source %s", testCase.sourceUrl)
+		assert.Equal(t, testCase.expected, *actual, "For source %s", testCase.sourceUrl)
+	}
+}
+
+func TestParseTerraformSourceErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl   string
+		expectedErr error
+	}{
+		{"https://example.com/modules.zip", UnsupportedSource("https://example.com/modules.zip")},
+		{"git::https://github.com/foo/modules.git?depth=1", UnsupportedSourceParam("depth")},
+		{"git:://app", UnsupportedSource("git:://app")},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseTerraformSource(testCase.sourceUrl, "/work/live")
+		assert.True(t, errors.IsError(err, testCase.expectedErr), "Unexpected error of type %s: %s", reflect.TypeOf(err), err)
+	}
+}
+
+func TestOverrideTerraformSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		sourceUrl string
+		override  string
+		expected  string
+	}{
+		{"git::https://github.com/foo/modules.git//modules/app?ref=v1.2", "/home/me/modules", "/home/me/modules//modules/app"},
+		{"git::https://github.com/foo/modules.git//app", "/home/me/modules/", "/home/me/modules//app"},
+		{"git::https://github.com/foo/modules.git?ref=v1.2", "/home/me/modules", "/home/me/modules"},
+		{"", "/home/me/modules", "/home/me/modules"},
+	}
+
+	for _, testCase := range testCases {
+		actual := OverrideTerraformSource(testCase.sourceUrl, testCase.override)
+		assert.Equal(t, testCase.expected, actual, "For source %s and override %s", testCase.sourceUrl, testCase.override)
+	}
+}
+
+func TestDownloadTerraformSourceLocal(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, filepath.Join(tmpDir, "modules", "app", "main.tf"), "# app")
+	writeFile(t, filepath.Join(tmpDir, "modules", "vpc", "main.tf"), "# vpc")
+	writeFile(t, filepath.Join(tmpDir, "live", ".terragrunt"), "# config")
+	writeFile(t, filepath.Join(tmpDir, "live", "terraform.tfvars"), "# vars")
+
+	terragruntOptions := testOptions(tmpDir)
+
+	terraformDir, err := DownloadTerraformSource("../modules//app", terragruntOptions)
+	assert.Nil(t, err)
+
+	assertFileContents(t, filepath.Join(terraformDir, "main.tf"), "# app")
+	assertFileContents(t, filepath.Join(terraformDir, "..", "vpc", "main.tf"), "# vpc")
+	assertFileContents(t, filepath.Join(terraformDir, ".terragrunt"), "# config")
+	assertFileContents(t, filepath.Join(terraformDir, "terraform.tfvars"), "# vars")
+
+	// Local sources are copied again on every run, so changes show up right away
+	writeFile(t, filepath.Join(tmpDir, "modules", "app", "main.tf"), "# app v2")
+
+	terraformDir, err = DownloadTerraformSource("../modules//app", terragruntOptions)
+	assert.Nil(t, err)
+	assertFileContents(t, filepath.Join(terraformDir, "main.tf"), "# app v2")
+}
+
+func TestDownloadTerraformSourceKeepsStateAcrossRuns(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir := filepath.Join(tmpDir, "live")
+	writeFile(t, filepath.Join(tmpDir, "modules", "app", "main.tf"), "# app")
+	writeFile(t, filepath.Join(workingDir, ".terragrunt"), "# config")
+
+	terragruntOptions := testOptions(tmpDir)
+
+	// First run: Terraform writes local state and its .terraform folder in the download folder
+	terraformDir, err := DownloadTerraformSource("../modules//app", terragruntOptions)
+	assert.Nil(t, err)
+	writeFile(t, filepath.Join(terraformDir, "terraform.tfstate"), "# state v1")
+	writeFile(t, filepath.Join(terraformDir, "terraform.tfstate.d", "stage", "terraform.tfstate"), "# stage state v1")
+	writeFile(t, filepath.Join(terraformDir, ".terraform", "modules", "vpc", "main.tf"), "# module")
+	assert.Nil(t, CopyStateFiles(terraformDir, workingDir))
+
+	assertFileContents(t, filepath.Join(workingDir, "terraform.tfstate"), "# state v1")
+	assertFileContents(t, filepath.Join(workingDir, "terraform.tfstate.d", "stage", "terraform.tfstate"), "# stage state v1")
+
+	// Second run: the local source is copied again, but the state and the .terraform folder are still there
+	terraformDir, err = DownloadTerraformSource("../modules//app", terragruntOptions)
+	assert.Nil(t, err)
+	assertFileContents(t, filepath.Join(terraformDir, "terraform.tfstate"), "# state v1")
+	assertFileContents(t, filepath.Join(terraformDir, "terraform.tfstate.d", "stage", "terraform.tfstate"), "# stage state v1")
+	assertFileContents(t, filepath.Join(terraformDir, ".terraform", "modules", "vpc", "main.tf"), "# module")
+
+	writeFile(t, filepath.Join(terraformDir, "terraform.tfstate"), "# state v2")
+	assert.Nil(t, CopyStateFiles(terraformDir, workingDir))
+	assertFileContents(t, filepath.Join(workingDir, "terraform.tfstate"), "# state v2")
+}
+
+func TestDownloadTerraformSourceLocalSubdirNotFound(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeFile(t, filepath.Join(tmpDir, "modules", "app", "main.tf"), "# app")
+	writeFile(t, filepath.Join(tmpDir, "live", ".terragrunt"), "# config")
+
+	_, err := DownloadTerraformSource("../modules//does-not-exist", testOptions(tmpDir))
+	assert.IsType(t, SourceSubdirNotFound{}, errors.Unwrap(err))
+}
+
+func TestDownloadTerraformSourceGit(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := createTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	writeFile(t, filepath.Join(repoDir, "app", "main.tf"), "# app v1")
+	runGit(t, repoDir, "init", "--quiet")
+	runGit(t, repoDir, "add", "-A")
+	runGit(t, repoDir, "commit", "--quiet", "-m", "v1")
+	runGit(t, repoDir, "tag", "v1")
+	writeFile(t, filepath.Join(repoDir, "app", "main.tf"), "# app v2")
+	runGit(t, repoDir, "commit", "--quiet", "-a", "-m", "v2")
+
+	writeFile(t, filepath.Join(tmpDir, "live", ".terragrunt"), "# config")
+	terragruntOptions := testOptions(tmpDir)
+
+	terraformDir, err := DownloadTerraformSource("git::" + repoDir + "//app?ref=v1", terragruntOptions)
+	assert.Nil(t, err)
+	assertFileContents(t, filepath.Join(terraformDir, "main.tf"), "# app v1")
+	assertFileContents(t, filepath.Join(terraformDir, ".terragrunt"), "# config")
+
+	terraformDir, err = DownloadTerraformSource("git::" + repoDir + "//app", terragruntOptions)
+	assert.Nil(t, err)
+	assertFileContents(t, filepath.Join(terraformDir, "main.tf"), "# app v2")
+}
+
+// Return options that run in the live folder of the given temp dir and download into its downloads folder
+func testOptions(tmpDir string) *options.TerragruntOptions {
+	terragruntOptions := options.NewTerragruntOptionsForTest(filepath.Join(tmpDir, "live", ".terragrunt"))
+	terragruntOptions.WorkingDir = filepath.Join(tmpDir, "live")
+	terragruntOptions.DownloadDir = filepath.Join(tmpDir, "downloads")
+	terragruntOptions.Env = nil
+	terragruntOptions.Writer = ioutil.Discard
+	terragruntOptions.ErrWriter = ioutil.Discard
+	return terragruntOptions
+}
+
+func createTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "terragrunt-source-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileContents(t *testing.T, path string, expected string) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, string(bytes), "Contents of %s", path)
+}
+
+func runGit(t *testing.T, dir string, args ... string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Terragrunt", "-c", "user.email=terragrunt@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %s\n%s", args, err, out)
+	}
+}
@@ -8,12 +8,22 @@ import (
 	"regexp"
 )
 
+// The name of the folder in the user's home directory where Terragrunt keeps its own files
+const TERRAGRUNT_HOME_DIR_NAME = ".terragrunt"
+
 // Return true if the given file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
 
+// Return true if the given path exists and is a file rather than a folder. Terragrunt keeps its own files in a folder
+// named .terragrunt in the home directory, which has the same name as a Terragrunt config file.
+func IsFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 // Returns true if the given regex can be found in any of the files matched by the given glob
 func Grep(regex *regexp.Regexp, glob string) (bool, error) {
 	matches, err := filepath.Glob(glob)
@@ -33,4 +43,14 @@ func Grep(regex *regexp.Regexp, glob string) (bool, error) {
 	}
 
 	return false, nil
-}
\ No newline at end of file
+}
+
+// Return the folder in the current user's home directory where Terragrunt keeps its own files (e.g. ~/.terragrunt)
+func GetTerragruntHomeDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	return filepath.Join(homeDir, TERRAGRUNT_HOME_DIR_NAME), nil
+}
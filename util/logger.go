@@ -5,5 +5,6 @@ import (
 	"log"
 )
 
-// A simple logger we can use to get consistent log formatting through out the app
-var Logger = log.New(os.Stdout, "[terragrunt] ", log.LstdFlags)
+// A simple logger we can use to get consistent log formatting through out the app. Secrets are redacted from
+// everything it logs.
+var Logger = log.New(NewRedactingWriter(os.Stdout), "[terragrunt] ", log.LstdFlags)
@@ -0,0 +1,202 @@
+package util
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Secrets are replaced with this text in everything Terragrunt logs
+const REDACTED = "<redacted>"
+
+// Names of arguments, variables, backend configs, outputs, and resource attributes that look like they hold secrets,
+// e.g. db_password, secret_key, or token. Their values are always redacted from everything Terragrunt logs, and
+// state-scan reports them. Dashes should be replaced with underscores before matching, so -api-key matches too.
+var SENSITIVE_KEY_REGEX = regexp.MustCompile(`(?i)password|passwd|secret|token|access_key|private_key|api_key|credential|key_material`)
+
+// The secrets, sensitive key names, and patterns that are redacted from everything Terragrunt logs
+var redactions = newRedactor()
+
+// Keeps track of what to redact from log messages. It's safe to use from multiple goroutines.
+type redactor struct {
+	secrets  map[string]bool
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+	mutex    sync.Mutex
+}
+
+func newRedactor() *redactor {
+	return &redactor{secrets: map[string]bool{}, keys: map[string]bool{}}
+}
+
+// Register the given value as a secret, such as a decrypted value from the Terragrunt config, so it's redacted
+// wherever it appears in everything Terragrunt logs from now on. Empty values are ignored.
+func RegisterSecret(value string) {
+	redactions.registerSecret(value)
+}
+
+// Register the given name of an argument, variable, or backend config as sensitive, so its value is redacted from the
+// commands Terragrunt logs from now on, in addition to the names that match SENSITIVE_KEY_REGEX
+func RegisterSensitiveKey(key string) {
+	redactions.registerSensitiveKey(key)
+}
+
+// Register the given regex, so its matches are redacted from everything Terragrunt logs from now on
+func RegisterSensitivePattern(pattern *regexp.Regexp) {
+	redactions.registerSensitivePattern(pattern)
+}
+
+// Return the given log message with all registered secrets and matches of registered patterns redacted
+func Redact(message string) string {
+	return redactions.redact(message)
+}
+
+// Return the given command and its arguments as a single line for the log, with the values of sensitive arguments,
+// all registered secrets, and matches of registered patterns redacted. The value of a sensitive key is redacted in
+// each of these forms: key=value, -key value, -key=value, and -backend-config=key=value (or any other option whose
+// value is of the form key=value, such as -var=key=value).
+func RedactCommand(command string, args []string) string {
+	return redactions.redactCommand(command, args)
+}
+
+// Return true if the value of the argument, variable, or backend config with the given name should be redacted: if the
+// name matches SENSITIVE_KEY_REGEX or was registered with RegisterSensitiveKey
+func IsSensitiveKey(key string) bool {
+	return redactions.isSensitiveKey(key)
+}
+
+// Return a writer that redacts everything written to it, as Redact does, before writing it to the given writer. Each
+// write should be a whole log message, as it is for a log.Logger.
+func NewRedactingWriter(out io.Writer) io.Writer {
+	return redactingWriter{out: out, redactor: redactions}
+}
+
+func (redactor *redactor) registerSecret(value string) {
+	if value == "" {
+		return
+	}
+
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	redactor.secrets[value] = true
+}
+
+func (redactor *redactor) registerSensitiveKey(key string) {
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	redactor.keys[normalizeKey(key)] = true
+}
+
+func (redactor *redactor) registerSensitivePattern(pattern *regexp.Regexp) {
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	redactor.patterns = append(redactor.patterns, pattern)
+}
+
+func (redactor *redactor) redact(message string) string {
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	// Longer secrets go first, so a secret that contains another one is redacted completely
+	secrets := []string{}
+	for secret := range redactor.secrets {
+		secrets = append(secrets, secret)
+	}
+	sort.Sort(byLengthDescending(secrets))
+
+	for _, secret := range secrets {
+		message = strings.Replace(message, secret, REDACTED, -1)
+	}
+
+	for _, pattern := range redactor.patterns {
+		message = pattern.ReplaceAllString(message, REDACTED)
+	}
+
+	return message
+}
+
+func (redactor *redactor) redactCommand(command string, args []string) string {
+	redactedArgs := []string{}
+
+	for i, arg := range args {
+		if i > 0 && redactor.isSensitiveOption(args[i - 1]) {
+			redactedArgs = append(redactedArgs, REDACTED)
+		} else {
+			redactedArgs = append(redactedArgs, redactor.redactArg(arg))
+		}
+	}
+
+	return redactor.redact(command + " " + strings.Join(redactedArgs, " "))
+}
+
+// Redact the value of the given argument if it's of the form key=value, -key=value, or -option=key=value, and the key
+// is sensitive
+func (redactor *redactor) redactArg(arg string) string {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) < 2 {
+		return arg
+	}
+
+	key, value := parts[0], parts[1]
+	if redactor.isSensitiveKey(key) {
+		return key + "=" + REDACTED
+	}
+
+	if strings.HasPrefix(key, "-") {
+		return key + "=" + redactor.redactArg(value)
+	}
+
+	return arg
+}
+
+// Return true if the given argument is an option, such as -token, whose value is the next argument and is sensitive
+func (redactor *redactor) isSensitiveOption(arg string) bool {
+	return strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && redactor.isSensitiveKey(arg)
+}
+
+// Return true if the value of the argument, variable, or backend config with the given name should be redacted
+func (redactor *redactor) isSensitiveKey(key string) bool {
+	normalizedKey := normalizeKey(key)
+	if normalizedKey == "" {
+		return false
+	}
+
+	if SENSITIVE_KEY_REGEX.MatchString(normalizedKey) {
+		return true
+	}
+
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	return redactor.keys[normalizedKey]
+}
+
+// Return the given key without leading dashes, lower-cased, and with dashes replaced with underscores, so -Access-Key
+// and access_key are the same key
+func normalizeKey(key string) string {
+	return strings.Replace(strings.ToLower(strings.TrimLeft(key, "-")), "-", "_", -1)
+}
+
+// Redacts everything written to it before writing it to another writer
+type redactingWriter struct {
+	out      io.Writer
+	redactor *redactor
+}
+
+func (writer redactingWriter) Write(p []byte) (int, error) {
+	if _, err := writer.out.Write([]byte(writer.redactor.redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type byLengthDescending []string
+
+func (values byLengthDescending) Len() int           { return len(values) }
+func (values byLengthDescending) Swap(i, j int)      { values[i], values[j] = values[j], values[i] }
+func (values byLengthDescending) Less(i, j int) bool { return len(values[i]) > len(values[j]) }